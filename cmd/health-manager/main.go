@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"health-manager/internal/backup"
 	"health-manager/internal/database"
 	"health-manager/internal/handlers"
 	"health-manager/internal/middleware"
+	"health-manager/internal/notify"
+	"health-manager/internal/reminder"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
@@ -17,14 +24,44 @@ import (
 )
 
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run 启动服务并阻塞到收到退出信号，返回前依次执行 defer 的清理
+func run() error {
 	// 设置日志输出到文件
 	f, _ := os.OpenFile("app.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
 	gin.DefaultWriter = io.MultiWriter(f, os.Stdout)
 
 	// 初始化数据库
-	if err := database.InitDB(); err != nil {
-		log.Fatal("数据库初始化失败:", err)
+	db, err := database.InitDB()
+	if err != nil {
+		return fmt.Errorf("数据库初始化失败: %w", err)
 	}
+	defer db.Close()
+
+	// 启动自动备份调度
+	backups := backup.NewScheduler(db, "data/backups")
+	backups.Start()
+	defer backups.Stop()
+
+	// 通知分发：提醒事件等发送到已注册的渠道
+	webhooks := notify.NewWebhooks(db)
+	mailer := notify.NewMailer(db)
+	notifier := notify.NewHub()
+	notifier.Register(notify.LogChannel{})
+	notifier.Register(webhooks)
+	notifier.Register(mailer)
+	defer notifier.Wait()
+
+	h := handlers.New(db, backups, notifier, webhooks, mailer)
+
+	// 启动测量提醒检查，按用户时区判断提醒时间
+	reminders := reminder.NewScheduler(db, notifier, h.UserLocation)
+	reminders.Start()
+	defer reminders.Stop()
 
 	r := gin.Default()
 
@@ -41,50 +78,128 @@ func main() {
 	})
 
 	// 公开API
-	r.POST("/api/login", handlers.Login)
-	r.POST("/api/logout", handlers.Logout)
-	r.GET("/api/me", handlers.GetCurrentUser)
+	r.POST("/api/login", h.Login)
+	r.POST("/api/logout", h.Logout)
+	r.GET("/api/me", h.GetCurrentUser)
 
 	// 用户API (需要登录)
 	userAPI := r.Group("/api")
-	userAPI.Use(middleware.AuthRequired())
+	userAPI.Use(middleware.AuthRequired(db))
 	{
-		userAPI.GET("/bp", handlers.GetBPRecords)
-		userAPI.POST("/bp", handlers.CreateBP)
-		userAPI.DELETE("/bp/:id", handlers.DeleteBP)
+		userAPI.GET("/bp", h.GetBPRecords)
+		userAPI.POST("/bp", h.CreateBP)
+		userAPI.PUT("/bp/:id", h.UpdateBP)
+		userAPI.PATCH("/bp/:id", h.UpdateBP)
+		userAPI.DELETE("/bp/:id", h.DeleteBP)
+		userAPI.GET("/measurements/types", h.GetMeasurementTypes)
+		userAPI.GET("/measurements", h.GetMeasurements)
+		userAPI.POST("/measurements", h.CreateMeasurement)
+		userAPI.PUT("/measurements/:id", h.UpdateMeasurement)
+		userAPI.PATCH("/measurements/:id", h.UpdateMeasurement)
+		userAPI.DELETE("/measurements/:id", h.DeleteMeasurement)
+		userAPI.GET("/glucose", h.GetGlucose)
+		userAPI.POST("/glucose", h.CreateGlucose)
+		userAPI.PUT("/glucose/:id", h.UpdateGlucose)
+		userAPI.PATCH("/glucose/:id", h.UpdateGlucose)
+		userAPI.DELETE("/glucose/:id", h.DeleteGlucose)
+		userAPI.GET("/glucose/targets", h.GetGlucoseTargets)
+		userAPI.PUT("/glucose/targets", h.SetGlucoseTargets)
+		userAPI.GET("/medications", h.GetMedications)
+		userAPI.POST("/medications", h.CreateMedication)
+		userAPI.PUT("/medications/:id", h.UpdateMedication)
+		userAPI.PATCH("/medications/:id", h.UpdateMedication)
+		userAPI.DELETE("/medications/:id", h.DeleteMedication)
+		userAPI.GET("/medications/intakes", h.GetIntakes)
+		userAPI.POST("/medications/intakes", h.CreateIntake)
+		userAPI.DELETE("/medications/intakes/:id", h.DeleteIntake)
+		userAPI.GET("/bp/timeline", h.GetTimeline)
+		userAPI.GET("/bp/stats", h.GetBPStats)
+		userAPI.GET("/bp/analysis", h.GetBPAnalysis)
+		userAPI.GET("/alerts", h.GetAlerts)
+		userAPI.POST("/alerts/:id/read", h.MarkAlertRead)
 	}
 
 	// 管理员API (需要管理员权限)
 	adminAPI := r.Group("/api/admin")
-	adminAPI.Use(middleware.AuthRequired(), middleware.AdminRequired())
+	adminAPI.Use(middleware.AuthRequired(db), middleware.AdminRequired())
 	{
-		adminAPI.GET("/users", handlers.GetUsers)
-		adminAPI.POST("/users", handlers.CreateUser)
-		adminAPI.DELETE("/users/:id", handlers.DeleteUser)
-		adminAPI.PUT("/users/:id/password", handlers.ChangeUserPassword)
-		adminAPI.PUT("/users/:id/role", handlers.ToggleAdminRole)
-		adminAPI.GET("/db-config", handlers.GetDBConfig)
-		adminAPI.POST("/db-config", handlers.SaveDBConfig)
-		adminAPI.POST("/db-config/test", handlers.TestDBConfig)
-		adminAPI.POST("/db/backup", handlers.BackupDatabase)
-		adminAPI.POST("/db/restore", handlers.RestoreDatabase)
-		adminAPI.POST("/settings/idle-timeout", handlers.SetIdleTimeout)
+		adminAPI.GET("/users", h.GetUsers)
+		adminAPI.POST("/users", h.CreateUser)
+		adminAPI.DELETE("/users/:id", h.DeleteUser)
+		adminAPI.PUT("/users/:id/password", h.ChangeUserPassword)
+		adminAPI.PUT("/users/:id/role", h.ToggleAdminRole)
+		adminAPI.GET("/db-config", h.GetDBConfig)
+		adminAPI.POST("/db-config", h.SaveDBConfig)
+		adminAPI.POST("/db-config/test", h.TestDBConfig)
+		adminAPI.GET("/db/backup", h.BackupDatabase)
+		adminAPI.POST("/db/restore", h.RestoreDatabase)
+		adminAPI.GET("/backups", h.GetBackups)
+		adminAPI.PUT("/backups/config", h.SaveBackupConfig)
+		adminAPI.POST("/backups/run", h.RunBackup)
+		adminAPI.GET("/backups/files/:name", h.DownloadBackup)
+		adminAPI.GET("/webhooks", h.GetWebhooks)
+		adminAPI.PUT("/webhooks", h.SaveWebhooks)
+		adminAPI.POST("/webhooks/:id/test", h.TestWebhook)
+		adminAPI.GET("/smtp", h.GetSMTPConfig)
+		adminAPI.PUT("/smtp", h.SaveSMTPConfig)
+		adminAPI.POST("/smtp/test", h.TestSMTP)
+		adminAPI.POST("/settings/idle-timeout", h.SetIdleTimeout)
+		adminAPI.POST("/settings/time-zone", h.SetDefaultTimeZone)
+		adminAPI.POST("/settings/glucose-targets", h.SetGlobalGlucoseTargets)
+		adminAPI.POST("/settings/bp-guideline", h.SetDefaultBPGuideline)
+		adminAPI.POST("/settings/bmi-standard", h.SetDefaultBMIStandard)
 	}
 
 	// 通用设置API (需要登录)
-	userAPI.GET("/settings/idle-timeout", handlers.GetIdleTimeout)
+	userAPI.GET("/settings/idle-timeout", h.GetIdleTimeout)
+	userAPI.GET("/settings/time-zone", h.GetTimeZone)
+	userAPI.PUT("/settings/time-zone", h.SetTimeZone)
+	userAPI.GET("/settings/bp-guideline", h.GetBPGuideline)
+	userAPI.PUT("/settings/bp-guideline", h.SetBPGuideline)
+	userAPI.GET("/settings/bmi-standard", h.GetBMIStandard)
+	userAPI.PUT("/settings/bmi-standard", h.SetBMIStandard)
+	userAPI.GET("/settings/bp-windows", h.GetBPWindows)
+	userAPI.PUT("/settings/bp-windows", h.SetBPWindows)
+	userAPI.GET("/settings/alert-rules", h.GetAlertRules)
+	userAPI.PUT("/settings/alert-rules", h.SetAlertRules)
+	userAPI.GET("/settings/email", h.GetEmailSettings)
+	userAPI.PUT("/settings/email", h.SetEmailSettings)
+	userAPI.GET("/settings/reminders", h.GetReminders)
+	userAPI.PUT("/settings/reminders", h.SetReminders)
 
 	log.Println("健康管理系统启动在 http://localhost:8080")
 
-	// 使用自定义 http.Server 配置超时，防止慢速连接攻击 (DoS)
+	// 使用自定义 http.Server 配置超时，防止慢速连接攻击 (DoS)；
+	// 备份下载与还原上传会在处理时单独延长读写时限
 	server := &http.Server{
-		Addr:         ":8080",
-		Handler:      r,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		Addr:              ":8080",
+		Handler:           r,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	// 收到 SIGINT/SIGTERM 时停止接收新请求，等待进行中的请求结束后返回，
+	// 使上面 defer 的调度器停止、通知发送等待和数据库关闭得以执行
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- server.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("服务器启动失败: %w", err)
+	case <-ctx.Done():
 	}
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatal("服务器启动失败:", err)
+	stop()
+
+	log.Println("正在关闭服务器...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("服务器关闭失败: %w", err)
 	}
+	return nil
 }
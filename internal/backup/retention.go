@@ -0,0 +1,66 @@
+package backup
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// prune 按"日/周/月"保留策略删除多余的备份：
+// 从最新的备份开始，每天、每周、每月各保留最新的一份，分别最多保留 KeepDaily/KeepWeekly/KeepMonthly 个周期。
+// 三个保留数都为0时不清理。
+func (s *Scheduler) prune(cfg Config) error {
+	if cfg.KeepDaily == 0 && cfg.KeepWeekly == 0 && cfg.KeepMonthly == 0 {
+		return nil
+	}
+
+	files, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	keep := retained(files, cfg)
+	for _, f := range files {
+		if keep[f.Name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, f.Name)); err != nil {
+			return err
+		}
+		log.Printf("已清理过期备份: %s", f.Name)
+	}
+	return nil
+}
+
+// retained 计算需要保留的备份文件，files 须按时间倒序
+func retained(files []File, cfg Config) map[string]bool {
+	keep := make(map[string]bool)
+	periods := []struct {
+		limit int
+		key   func(f File) string
+	}{
+		{cfg.KeepDaily, func(f File) string { return f.Time.Format("2006-01-02") }},
+		{cfg.KeepWeekly, func(f File) string {
+			year, week := f.Time.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{cfg.KeepMonthly, func(f File) string { return f.Time.Format("2006-01") }},
+	}
+
+	for _, p := range periods {
+		seen := make(map[string]bool)
+		for _, f := range files {
+			if len(seen) >= p.limit {
+				break
+			}
+			k := p.key(f)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			keep[f.Name] = true
+		}
+	}
+	return keep
+}
@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// backupFiles 按时间倒序生成备份文件列表，times 为 "2006-01-02 15:04"
+func backupFiles(times ...string) []File {
+	var files []File
+	for _, v := range times {
+		t, _ := time.ParseInLocation("2006-01-02 15:04", v, time.Local)
+		files = append(files, File{Name: filePrefix + t.Format(fileTimeLayout) + fileSuffix, Time: t})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Time.After(files[j].Time) })
+	return files
+}
+
+// keptTimes 返回被保留的备份时间，按时间倒序
+func keptTimes(files []File, keep map[string]bool) []string {
+	var kept []string
+	for _, f := range files {
+		if keep[f.Name] {
+			kept = append(kept, f.Time.Format("2006-01-02 15:04"))
+		}
+	}
+	return kept
+}
+
+func TestRetained(t *testing.T) {
+	// 2024-03-04 是星期一
+	files := backupFiles(
+		"2024-03-06 15:00", "2024-03-06 03:00",
+		"2024-03-05 03:00",
+		"2024-03-04 03:00",
+		"2024-03-03 03:00",
+		"2024-02-26 03:00",
+		"2024-02-01 03:00",
+		"2024-01-15 03:00", "2024-01-01 03:00",
+		"2023-12-31 03:00",
+	)
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{"每天一份", Config{KeepDaily: 3}, []string{"2024-03-06 15:00", "2024-03-05 03:00", "2024-03-04 03:00"}},
+		{"每周一份", Config{KeepWeekly: 3}, []string{"2024-03-06 15:00", "2024-03-03 03:00", "2024-02-01 03:00"}},
+		{"每月一份", Config{KeepMonthly: 4}, []string{"2024-03-06 15:00", "2024-02-26 03:00", "2024-01-15 03:00", "2023-12-31 03:00"}},
+		{"合并三种周期", Config{KeepDaily: 2, KeepWeekly: 2, KeepMonthly: 3}, []string{
+			"2024-03-06 15:00", "2024-03-05 03:00", "2024-03-03 03:00", "2024-02-26 03:00", "2024-01-15 03:00",
+		}},
+		{"保留数多于备份数", Config{KeepDaily: 100}, []string{
+			"2024-03-06 15:00", "2024-03-05 03:00", "2024-03-04 03:00", "2024-03-03 03:00",
+			"2024-02-26 03:00", "2024-02-01 03:00", "2024-01-15 03:00", "2024-01-01 03:00", "2023-12-31 03:00",
+		}},
+		{"不保留", Config{}, nil},
+	}
+	for _, tt := range tests {
+		got := keptTimes(files, retained(files, tt.cfg))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: kept %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: kept %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	files := backupFiles("2024-03-06 03:00", "2024-03-05 15:00", "2024-03-05 03:00", "2024-03-04 03:00")
+	// 加密备份和备份目录中的其他文件
+	files[1].Name += encSuffix
+	for _, name := range append(names(files), "notes.txt") {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &Scheduler{dir: dir}
+	if err := s.prune(Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if left, _ := s.List(); len(left) != len(files) {
+		t.Fatalf("prune with no limits removed files: %d left", len(left))
+	}
+
+	if err := s.prune(Config{KeepDaily: 2}); err != nil {
+		t.Fatal(err)
+	}
+	left, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(left), []string{files[0].Name, files[1].Name}; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("left %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("non-backup file removed: %v", err)
+	}
+}
+
+// names 返回备份文件名列表
+func names(files []File) []string {
+	var list []string
+	for _, f := range files {
+		list = append(list, f.Name)
+	}
+	return list
+}
@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"0 3 * *",
+		"0 3 * * * *",
+		"60 3 * * *",
+		"0 24 * * *",
+		"0 3 0 * *",
+		"0 3 * 13 *",
+		"0 3 * * 7",
+		"0 3 5-1 * *",
+		"*/0 * * * *",
+		"a 3 * * *",
+		"@every 30s",
+		"@every soon",
+		"@yearly",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2024-03-05 是星期二
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{"0 3 * * *", "2024-03-05 02:59", "2024-03-05 03:00"},
+		{"0 3 * * *", "2024-03-05 03:00", "2024-03-06 03:00"},
+		{"@daily", "2024-03-05 10:00", "2024-03-06 03:00"},
+		{"@hourly", "2024-03-05 10:30", "2024-03-05 11:00"},
+		{"@weekly", "2024-03-05 10:00", "2024-03-10 03:00"},
+		{"@monthly", "2024-03-05 10:00", "2024-04-01 03:00"},
+		{"*/15 * * * *", "2024-03-05 10:01", "2024-03-05 10:15"},
+		{"30 8-18/4 * * *", "2024-03-05 13:00", "2024-03-05 16:30"},
+		{"0 0 1,15 * *", "2024-03-05 10:00", "2024-03-15 00:00"},
+		{"0 0 31 * *", "2024-04-01 10:00", "2024-05-31 00:00"},
+		{"0 3 * 12 *", "2024-03-05 10:00", "2024-12-01 03:00"},
+		{"0 3 * * 1-5", "2024-03-08 10:00", "2024-03-11 03:00"},
+
+		// 日和周都有限定时满足其一即可
+		{"0 3 15 * 1", "2024-03-05 10:00", "2024-03-11 03:00"},
+		{"0 3 6 * 1", "2024-03-05 10:00", "2024-03-06 03:00"},
+		// 以 * 开头的字段不算限定，只看另一个字段
+		{"0 3 */2 * 1", "2024-03-05 10:00", "2024-03-11 03:00"},
+		{"0 3 6 * */2", "2024-03-06 10:00", "2024-04-06 03:00"},
+		{"0 3 */10 * *", "2024-03-05 10:00", "2024-03-11 03:00"},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.spec, err)
+			continue
+		}
+		from, _ := time.Parse("2006-01-02 15:04", tt.from)
+		got := s.Next(from)
+		if got.Format("2006-01-02 15:04") != tt.want {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.spec, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestScheduleNextNever(t *testing.T) {
+	// 2月30日不存在；下一个2月29日在一年之后
+	for _, spec := range []string{"0 0 30 2 *", "0 0 29 2 *"} {
+		s, err := ParseSchedule(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Next(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+			t.Errorf("%q.Next = %s, want zero time", spec, got)
+		}
+	}
+}
+
+func TestEverySchedule(t *testing.T) {
+	s, err := ParseSchedule("@every 6h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 3, 5, 10, 0, 30, 500, time.UTC)
+	if got, want := s.Next(from), time.Date(2024, 3, 5, 16, 0, 30, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
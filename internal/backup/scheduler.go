@@ -0,0 +1,360 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"health-manager/internal/database"
+)
+
+// settingKey 自动备份配置在全局设置中的键名
+const settingKey = "backup_schedule"
+
+// passphraseKey 备份密码单独保存在凭据设置中，不随逻辑备份导出
+const passphraseKey = database.SecretSettingPrefix + "backup_passphrase"
+
+const (
+	filePrefix     = "health_backup_"
+	fileSuffix     = ".ndjson"
+	encSuffix      = ".enc" // 加密备份在文件名后追加
+	fileTimeLayout = "20060102_150405"
+)
+
+// Config 自动备份配置
+type Config struct {
+	Enabled     bool   `json:"enabled"`
+	Schedule    string `json:"schedule"`     // cron表达式或 @daily、@every 6h 等
+	KeepDaily   int    `json:"keep_daily"`   // 保留最近N天（每天一份）
+	KeepWeekly  int    `json:"keep_weekly"`  // 保留最近N周（每周一份）
+	KeepMonthly int    `json:"keep_monthly"` // 保留最近N月（每月一份）
+	Encrypt     bool   `json:"encrypt"`      // 是否加密备份
+	Passphrase  string `json:"passphrase,omitempty"`
+}
+
+// DefaultConfig 默认配置：关闭，每天凌晨3点，保留7天/4周/6个月
+func DefaultConfig() Config {
+	return Config{
+		Schedule:    "0 3 * * *",
+		KeepDaily:   7,
+		KeepWeekly:  4,
+		KeepMonthly: 6,
+	}
+}
+
+// Validate 校验配置
+func (c Config) Validate() error {
+	if _, err := ParseSchedule(c.Schedule); err != nil {
+		return err
+	}
+	if c.KeepDaily < 0 || c.KeepWeekly < 0 || c.KeepMonthly < 0 {
+		return fmt.Errorf("保留份数不能为负数")
+	}
+	if c.Encrypt && c.Passphrase == "" {
+		return fmt.Errorf("加密备份需要设置密码")
+	}
+	return nil
+}
+
+// Status 自动备份运行状态
+type Status struct {
+	LastRun   time.Time `json:"last_run"`
+	LastFile  string    `json:"last_file"`
+	LastError string    `json:"last_error"`
+	NextRun   time.Time `json:"next_run"`
+}
+
+// File 备份目录中的一个备份文件
+type File struct {
+	Name string    `json:"name"`
+	Size int64     `json:"size"`
+	Time time.Time `json:"time"`
+}
+
+// Scheduler 按计划将数据库逻辑备份到目录中，并按保留策略清理旧备份
+type Scheduler struct {
+	db  *database.Manager
+	dir string
+
+	runMu  sync.Mutex // 保证同一时刻只有一个备份在执行
+	mu     sync.Mutex
+	status Status
+
+	reload chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewScheduler 创建自动备份调度器，备份文件保存在dir中
+func NewScheduler(db *database.Manager, dir string) *Scheduler {
+	return &Scheduler{
+		db:     db,
+		dir:    dir,
+		reload: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start 启动后台调度
+func (s *Scheduler) Start() {
+	if files, err := s.List(); err == nil && len(files) > 0 {
+		s.mu.Lock()
+		s.status.LastRun = files[0].Time
+		s.status.LastFile = files[0].Name
+		s.mu.Unlock()
+	}
+	go s.loop()
+}
+
+// Stop 停止后台调度并等待退出
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+// Reload 配置或数据库变更后重新计算下一次执行时间
+func (s *Scheduler) Reload() {
+	select {
+	case s.reload <- struct{}{}:
+	default:
+	}
+}
+
+// LoadConfig 从全局设置读取配置，未设置时返回默认配置
+func (s *Scheduler) LoadConfig() (Config, error) {
+	cfg := DefaultConfig()
+	value, err := s.db.GetSetting(settingKey)
+	if err != nil || value == "" {
+		return cfg, err
+	}
+	if err := json.Unmarshal([]byte(value), &cfg); err != nil {
+		return DefaultConfig(), err
+	}
+	cfg.Passphrase = ""
+	if cfg.Encrypt {
+		cfg.Passphrase, err = s.db.GetSetting(passphraseKey)
+	}
+	return cfg, err
+}
+
+// store 保存配置，密码写入凭据设置，配置中不含密码
+func (s *Scheduler) store(cfg Config) error {
+	if err := s.db.SetSetting(passphraseKey, cfg.Passphrase); err != nil {
+		return err
+	}
+	cfg.Passphrase = ""
+	data, _ := json.Marshal(cfg)
+	return s.db.SetSetting(settingKey, string(data))
+}
+
+// SaveConfig 校验并保存配置，立即生效。
+// 启用加密但未填写密码时沿用已保存的密码；关闭加密时清除密码
+func (s *Scheduler) SaveConfig(cfg Config) error {
+	if !cfg.Encrypt {
+		cfg.Passphrase = ""
+	} else if cfg.Passphrase == "" {
+		if old, err := s.LoadConfig(); err == nil {
+			cfg.Passphrase = old.Passphrase
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if err := s.store(cfg); err != nil {
+		return err
+	}
+	s.Reload()
+	return nil
+}
+
+// Status 返回运行状态
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+	for {
+		var (
+			timer *time.Timer
+			fire  <-chan time.Time
+		)
+		cfg, err := s.LoadConfig()
+		if err != nil {
+			log.Printf("读取自动备份配置失败: %v", err)
+		}
+		next := s.nextRun(cfg)
+		s.mu.Lock()
+		s.status.NextRun = next
+		s.mu.Unlock()
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
+		select {
+		case <-fire:
+			if _, err := s.run(cfg); err != nil {
+				log.Printf("自动备份失败: %v", err)
+			}
+		case <-s.reload:
+		case <-s.stop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// nextRun 计算下一次执行时间，未启用或计划无效时返回零值
+func (s *Scheduler) nextRun(cfg Config) time.Time {
+	if !cfg.Enabled {
+		return time.Time{}
+	}
+	sched, err := ParseSchedule(cfg.Schedule)
+	if err != nil {
+		log.Printf("自动备份计划无效: %v", err)
+		return time.Time{}
+	}
+	return sched.Next(time.Now())
+}
+
+// RunNow 立即执行一次备份并按当前配置清理旧备份
+func (s *Scheduler) RunNow() (File, error) {
+	cfg, err := s.LoadConfig()
+	if err != nil {
+		return File{}, err
+	}
+	return s.run(cfg)
+}
+
+func (s *Scheduler) run(cfg Config) (File, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	now := time.Now()
+	var file File
+	var err error
+	if cfg.Encrypt && cfg.Passphrase == "" {
+		// 如从备份还原到另一台服务器后凭据设置不存在，不能退化为未加密备份
+		err = fmt.Errorf("加密备份需要设置密码")
+	} else {
+		file, err = s.backup(now, cfg.Passphrase)
+	}
+
+	s.mu.Lock()
+	s.status.LastRun = now
+	s.status.LastFile = file.Name
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return file, err
+	}
+
+	if err := s.prune(cfg); err != nil {
+		log.Printf("清理旧备份失败: %v", err)
+	}
+	return file, nil
+}
+
+// backup 先写入临时文件，完成后再重命名，避免列表中出现不完整的备份
+func (s *Scheduler) backup(now time.Time, passphrase string) (File, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return File{}, err
+	}
+
+	name := filePrefix + now.Format(fileTimeLayout) + fileSuffix
+	if passphrase != "" {
+		name += encSuffix
+	}
+	path := filepath.Join(s.dir, name)
+	tmpPath := path + ".tmp"
+	if err := s.db.BackupDB(tmpPath, passphrase); err != nil {
+		os.Remove(tmpPath)
+		return File{}, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return File{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return File{}, err
+	}
+	log.Printf("自动备份完成: %s", path)
+	return File{Name: name, Size: info.Size(), Time: now}, nil
+}
+
+// List 列出备份目录中的备份文件，按时间倒序
+func (s *Scheduler) List() ([]File, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, e := range entries {
+		t, ok := parseFileTime(e.Name())
+		if e.IsDir() || !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, File{Name: e.Name(), Size: info.Size(), Time: t})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Time.After(files[j].Time)
+	})
+	return files, nil
+}
+
+// Path 返回备份文件的完整路径，只允许访问备份目录中的备份文件
+func (s *Scheduler) Path(name string) (string, error) {
+	if name != filepath.Base(name) {
+		return "", fmt.Errorf("无效的文件名")
+	}
+	if _, ok := parseFileTime(name); !ok {
+		return "", fmt.Errorf("无效的文件名")
+	}
+	path := filepath.Join(s.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("备份文件不存在")
+	}
+	return path, nil
+}
+
+// parseFileTime 从备份文件名中解析备份时间
+func parseFileTime(name string) (time.Time, bool) {
+	name = strings.TrimSuffix(name, encSuffix)
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+	t, err := time.ParseInLocation(fileTimeLayout, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -6,13 +6,21 @@ import (
 	"sync"
 )
 
+// 支持的数据库类型
+const (
+	TypeBolt     = "bolt"     // 本地bbolt键值文件（默认）
+	TypeSQLite   = "sqlite"   // 本地SQLite文件
+	TypeMySQL    = "mysql"    // 远程MySQL
+	TypePostgres = "postgres" // 远程PostgreSQL
+)
+
 // DBConfig 数据库配置
 type DBConfig struct {
-	Type     string `json:"type"`     // sqlite 或 mysql
-	Host     string `json:"host"`     // MySQL主机
-	Port     string `json:"port"`     // MySQL端口
-	User     string `json:"user"`     // MySQL用户名
-	Password string `json:"password"` // MySQL密码
+	Type     string `json:"type"`     // bolt、sqlite、mysql 或 postgres
+	Host     string `json:"host"`     // MySQL/PostgreSQL主机
+	Port     string `json:"port"`     // MySQL/PostgreSQL端口
+	User     string `json:"user"`     // MySQL/PostgreSQL用户名
+	Password string `json:"password"` // MySQL/PostgreSQL密码
 	DBName   string `json:"dbname"`   // 数据库名
 }
 
@@ -27,7 +35,7 @@ func GetConfig() *DBConfig {
 	configMutex.RLock()
 	defer configMutex.RUnlock()
 	if currentConfig == nil {
-		return &DBConfig{Type: "sqlite"}
+		return &DBConfig{Type: TypeBolt}
 	}
 	return currentConfig
 }
@@ -48,7 +56,7 @@ func LoadConfig() (*DBConfig, error) {
 	data, err := os.ReadFile(configFile)
 	if err != nil {
 		if os.IsNotExist(err) {
-			currentConfig = &DBConfig{Type: "sqlite"}
+			currentConfig = &DBConfig{Type: TypeBolt}
 			return currentConfig, nil
 		}
 		return nil, err
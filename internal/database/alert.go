@@ -0,0 +1,20 @@
+package database
+
+import "time"
+
+// Alert 提醒事件：新记录触发用户设置的提醒规则时生成。规则内容在触发时复制保存，规则修改后历史事件不变
+type Alert struct {
+	ID        int64   `json:"id"`
+	UserID    int64   `json:"user_id"`
+	RecordID  int64   `json:"record_id"` // 触发提醒的健康记录
+	Metric    string  `json:"metric"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	Value     float64 `json:"value"`
+	Message   string  `json:"message"`
+	// TriggeredAt 触发提醒的记录的测量时间
+	TriggeredAt time.Time `json:"triggered_at"`
+	// ReadAt 用户标记已读的时间，为空表示未读
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,251 @@
+package database
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 逻辑备份格式：NDJSON，第一行为文件头，其后每行一条用户/健康记录/测量记录/用药/提醒事件/设置。
+// 与具体数据库无关，bolt、SQLite、MySQL、PostgreSQL 均可导出和导入。
+const (
+	backupFormat        = "health-manager-backup"
+	backupFormatVersion = 5 // 版本2增加通用测量记录条目，版本3增加用户设置条目，版本4增加药物与服药记录条目，版本5增加提醒事件条目
+)
+
+// 备份条目类型
+const (
+	backupEntryUser    = "user"
+	backupEntryRecord  = "record"
+	backupEntrySetting = "setting"
+	backupEntryMeasure = "measurement"
+	backupEntryUserSet = "user_setting"
+	backupEntryMed     = "medication"
+	backupEntryIntake  = "intake"
+	backupEntryAlert   = "alert"
+)
+
+// SecretSettingPrefix 保存密码、密钥等凭据的全局设置键名前缀。逻辑备份不导出这类设置，
+// 以免未加密的备份或回滚副本泄露加密备份的密码等凭据；还原时保留当前的值
+const SecretSettingPrefix = "secret."
+
+// IsSecretSetting 判断全局设置是否保存凭据
+func IsSecretSetting(key string) bool {
+	return strings.HasPrefix(key, SecretSettingPrefix)
+}
+
+// BackupHeader 逻辑备份文件头
+type BackupHeader struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion int       `json:"schema_version"`
+	Source        string    `json:"source"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// backupEntry 逻辑备份中的一行数据
+type backupEntry struct {
+	Type        string            `json:"type"`
+	User        *User             `json:"user,omitempty"`
+	Record      *BloodPressure    `json:"record,omitempty"`
+	Measurement *Measurement      `json:"measurement,omitempty"`
+	Medication  *Medication       `json:"medication,omitempty"`
+	Intake      *MedicationIntake `json:"intake,omitempty"`
+	Alert       *Alert            `json:"alert,omitempty"`
+	UserID      int64             `json:"user_id,omitempty"` // 用户设置所属用户
+	Key         string            `json:"key,omitempty"`
+	Value       string            `json:"value,omitempty"`
+}
+
+// ExportBackup 将store中的全部数据以逻辑备份格式写入w
+func ExportBackup(store Store, source string, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	header := BackupHeader{
+		Format:        backupFormat,
+		Version:       backupFormatVersion,
+		SchemaVersion: latestSchemaVersion(),
+		Source:        source,
+		CreatedAt:     time.Now(),
+	}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+
+	err := store.ExportUsers(func(u User) error {
+		return enc.Encode(backupEntry{Type: backupEntryUser, User: &u})
+	})
+	if err != nil {
+		return fmt.Errorf("导出用户失败: %v", err)
+	}
+	err = store.ExportBPRecords(func(bp BloodPressure) error {
+		return enc.Encode(backupEntry{Type: backupEntryRecord, Record: &bp})
+	})
+	if err != nil {
+		return fmt.Errorf("导出健康记录失败: %v", err)
+	}
+	err = store.ExportMeasurements(func(m Measurement) error {
+		return enc.Encode(backupEntry{Type: backupEntryMeasure, Measurement: &m})
+	})
+	if err != nil {
+		return fmt.Errorf("导出测量记录失败: %v", err)
+	}
+	err = store.ExportMedications(func(med Medication) error {
+		return enc.Encode(backupEntry{Type: backupEntryMed, Medication: &med})
+	})
+	if err != nil {
+		return fmt.Errorf("导出药物失败: %v", err)
+	}
+	err = store.ExportIntakes(func(in MedicationIntake) error {
+		return enc.Encode(backupEntry{Type: backupEntryIntake, Intake: &in})
+	})
+	if err != nil {
+		return fmt.Errorf("导出服药记录失败: %v", err)
+	}
+	err = store.ExportAlerts(func(a Alert) error {
+		return enc.Encode(backupEntry{Type: backupEntryAlert, Alert: &a})
+	})
+	if err != nil {
+		return fmt.Errorf("导出提醒事件失败: %v", err)
+	}
+	err = store.ExportSettings(func(key, value string) error {
+		if IsSecretSetting(key) {
+			return nil
+		}
+		return enc.Encode(backupEntry{Type: backupEntrySetting, Key: key, Value: value})
+	})
+	if err != nil {
+		return fmt.Errorf("导出设置失败: %v", err)
+	}
+	err = store.ExportUserSettings(func(userID int64, key, value string) error {
+		return enc.Encode(backupEntry{Type: backupEntryUserSet, UserID: userID, Key: key, Value: value})
+	})
+	if err != nil {
+		return fmt.Errorf("导出用户设置失败: %v", err)
+	}
+
+	return bw.Flush()
+}
+
+// readBackup 解析逻辑备份，逐条回调fn；fn为nil时仅校验格式
+func readBackup(r io.Reader, fn func(e backupEntry) error) (*BackupHeader, DataCounts, error) {
+	var counts DataCounts
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	var header BackupHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, counts, fmt.Errorf("备份文件头无效: %v", err)
+	}
+	if header.Format != backupFormat {
+		return nil, counts, fmt.Errorf("不是有效的备份文件")
+	}
+	if header.Version > backupFormatVersion {
+		return nil, counts, fmt.Errorf("备份文件格式版本(%d)高于当前程序支持的版本(%d)", header.Version, backupFormatVersion)
+	}
+	if header.SchemaVersion > latestSchemaVersion() {
+		return nil, counts, fmt.Errorf("备份的数据库结构版本(%d)高于当前程序支持的版本(%d)", header.SchemaVersion, latestSchemaVersion())
+	}
+
+	for {
+		var e backupEntry
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, counts, fmt.Errorf("备份数据损坏: %v", err)
+		}
+
+		switch e.Type {
+		case backupEntryUser:
+			if e.User == nil {
+				return nil, counts, fmt.Errorf("备份数据损坏: 用户条目为空")
+			}
+			counts.Users++
+		case backupEntryRecord:
+			if e.Record == nil {
+				return nil, counts, fmt.Errorf("备份数据损坏: 健康记录条目为空")
+			}
+			counts.Records++
+		case backupEntryMeasure:
+			if e.Measurement == nil {
+				return nil, counts, fmt.Errorf("备份数据损坏: 测量记录条目为空")
+			}
+			counts.Measurements++
+		case backupEntryMed:
+			if e.Medication == nil {
+				return nil, counts, fmt.Errorf("备份数据损坏: 药物条目为空")
+			}
+			counts.Medications++
+		case backupEntryIntake:
+			if e.Intake == nil {
+				return nil, counts, fmt.Errorf("备份数据损坏: 服药记录条目为空")
+			}
+			counts.Intakes++
+		case backupEntryAlert:
+			if e.Alert == nil {
+				return nil, counts, fmt.Errorf("备份数据损坏: 提醒事件条目为空")
+			}
+			counts.Alerts++
+		case backupEntrySetting:
+			counts.Settings++
+		case backupEntryUserSet:
+			counts.UserSettings++
+		default:
+			return nil, counts, fmt.Errorf("备份数据损坏: 未知条目类型 %q", e.Type)
+		}
+
+		if fn != nil {
+			if err := fn(e); err != nil {
+				return nil, counts, err
+			}
+		}
+	}
+	return &header, counts, nil
+}
+
+// importBackup 将逻辑备份写入store（调用前应先清空store）
+func importBackup(store Store, r io.Reader) (DataCounts, error) {
+	_, counts, err := readBackup(r, func(e backupEntry) error {
+		switch e.Type {
+		case backupEntryUser:
+			return store.ImportUser(*e.User)
+		case backupEntryRecord:
+			return store.ImportBPRecord(*e.Record)
+		case backupEntryMeasure:
+			return store.ImportMeasurement(*e.Measurement)
+		case backupEntryMed:
+			return store.ImportMedication(*e.Medication)
+		case backupEntryIntake:
+			return store.ImportIntake(*e.Intake)
+		case backupEntryAlert:
+			return store.ImportAlert(*e.Alert)
+		case backupEntryUserSet:
+			return store.SetUserSetting(e.UserID, e.Key, e.Value)
+		default:
+			if isInternalMetaKey(e.Key) {
+				return nil
+			}
+			return store.SetSetting(e.Key, e.Value)
+		}
+	})
+	if err != nil {
+		return counts, err
+	}
+	return counts, finishImport(store)
+}
+
+// importFinisher 按原ID导入后需要额外处理的存储，如 PostgreSQL 需同步自增序列
+type importFinisher interface {
+	finishImport() error
+}
+
+// finishImport 在一批 Import* 之后调用
+func finishImport(store Store) error {
+	if f, ok := store.(importFinisher); ok {
+		return f.finishImport()
+	}
+	return nil
+}
@@ -0,0 +1,940 @@
+package database
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	boltDBPath       = "data/health_manager.db"
+	boltOldDBPath    = "data/blood_pressure.db"
+	boltLegacyDBPath = "data/blood_manager.db"
+)
+
+var (
+	usersBucket = []byte("users")
+	bpBucket    = []byte("blood_pressure") // 按用户分组，见 bolt_index.go
+	metaBucket  = []byte("meta")
+)
+
+// boltStore 基于bbolt键值文件的存储实现
+type boltStore struct {
+	db *bolt.DB
+}
+
+// openBolt 打开Bolt数据库
+func openBolt(dbPath string) (*boltStore, error) {
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrateBolt(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+// connectBolt 连接默认位置的Bolt数据库
+func connectBolt() (*boltStore, error) {
+	// 自动迁移：如果新数据库不存在但旧数据库存在，则重命名
+	if _, err := os.Stat(boltDBPath); os.IsNotExist(err) {
+		if _, err := os.Stat(boltLegacyDBPath); err == nil {
+			log.Printf("发现旧版数据库 %s，正在重命名为 %s...", boltLegacyDBPath, boltDBPath)
+			os.Rename(boltLegacyDBPath, boltDBPath)
+		} else if _, err := os.Stat(boltOldDBPath); err == nil {
+			log.Printf("发现旧版数据库 %s，正在重命名为 %s...", boltOldDBPath, boltDBPath)
+			os.Rename(boltOldDBPath, boltDBPath)
+		}
+	}
+
+	return openBolt(boltDBPath)
+}
+
+// Close 关闭数据库
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+func getNextID(tx *bolt.Tx, bucket []byte) int64 {
+	b := tx.Bucket(metaBucket)
+	key := append(bucket, []byte("_seq")...)
+	val := b.Get(key)
+	var id int64 = 1
+	if val != nil {
+		json.Unmarshal(val, &id)
+		id++
+	}
+	data, _ := json.Marshal(id)
+	b.Put(key, data)
+	return id
+}
+
+// ========== 用户操作 ==========
+
+// GetUserByUsername 根据用户名获取用户
+func (s *boltStore) GetUserByUsername(username string) (*User, error) {
+	var user *User
+	s.db.View(func(tx *bolt.Tx) error {
+		key := tx.Bucket(usersByNameBucket).Get([]byte(username))
+		if key == nil {
+			return nil
+		}
+		if data := tx.Bucket(usersBucket).Get(key); data != nil {
+			var u User
+			json.Unmarshal(data, &u)
+			user = &u
+		}
+		return nil
+	})
+
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return user, nil
+}
+
+// GetUserByID 根据ID获取用户
+func (s *boltStore) GetUserByID(id int64) (*User, error) {
+	var user *User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		u, err := getUser(tx, id)
+		user = u
+		return err
+	})
+	return user, err
+}
+
+// GetAllUsers 获取所有用户
+func (s *boltStore) GetAllUsers() ([]User, error) {
+	var users []User
+	s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var u User
+			json.Unmarshal(v, &u)
+			users = append(users, u)
+		}
+		return nil
+	})
+	return users, nil
+}
+
+// CreateUser 创建用户
+func (s *boltStore) CreateUser(username, hashedPassword, role string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		// 检查用户名是否已存在
+		if tx.Bucket(usersByNameBucket).Get([]byte(username)) != nil {
+			return fmt.Errorf("username already exists")
+		}
+
+		user := User{
+			ID:        getNextID(tx, usersBucket),
+			Username:  username,
+			Password:  hashedPassword,
+			Role:      role,
+			CreatedAt: time.Now(),
+		}
+		return putUser(tx, user)
+	})
+}
+
+// DeleteUser 删除用户及其健康记录
+func (s *boltStore) DeleteUser(id int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		// 删除用户的健康记录、通用测量记录、用药数据、提醒事件及ID索引
+		if err := deleteUserIndexed(tx, bpBucket, bpIDBucket, id); err != nil {
+			return err
+		}
+		if err := deleteUserIndexed(tx, measurementsBucket, measurementIDBucket, id); err != nil {
+			return err
+		}
+		if err := deleteUserIndexed(tx, intakesBucket, intakeIDBucket, id); err != nil {
+			return err
+		}
+		if err := deleteUserIndexed(tx, alertsBucket, alertIDBucket, id); err != nil {
+			return err
+		}
+		for _, name := range [][]byte{medicationsBucket, userSettingsBucket} {
+			if err := tx.Bucket(name).DeleteBucket(itob(id)); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+
+		user, err := getUser(tx, id)
+		if err != nil {
+			return nil
+		}
+		if err := unindexUser(tx, *user); err != nil {
+			return err
+		}
+		return tx.Bucket(usersBucket).Delete(userKey(id))
+	})
+}
+
+// UpdateUserPassword 更新用户密码
+func (s *boltStore) UpdateUserPassword(id int64, hashedPassword string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		user, err := getUser(tx, id)
+		if err != nil {
+			return err
+		}
+		user.Password = hashedPassword
+		return putUser(tx, *user)
+	})
+}
+
+// GetUserRole 获取用户角色
+func (s *boltStore) GetUserRole(id int64) string {
+	var role string
+	s.db.View(func(tx *bolt.Tx) error {
+		if u, err := getUser(tx, id); err == nil {
+			role = u.Role
+		}
+		return nil
+	})
+	return role
+}
+
+// CountAdmins 统计管理员数量
+func (s *boltStore) CountAdmins() int {
+	count := 0
+	s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(usersByRoleBucket).Bucket([]byte("admin")); b != nil {
+			count = b.Stats().KeyN
+		}
+		return nil
+	})
+	return count
+}
+
+// UpdateUserRole 更新用户角色
+func (s *boltStore) UpdateUserRole(id int64, role string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		user, err := getUser(tx, id)
+		if err != nil {
+			return err
+		}
+		user.Role = role
+		return putUser(tx, *user)
+	})
+}
+
+// GetUserTimeZone 获取用户时区
+func (s *boltStore) GetUserTimeZone(id int64) string {
+	var tz string
+	s.db.View(func(tx *bolt.Tx) error {
+		if u, err := getUser(tx, id); err == nil {
+			tz = u.TimeZone
+		}
+		return nil
+	})
+	return tz
+}
+
+// UpdateUserTimeZone 更新用户时区
+func (s *boltStore) UpdateUserTimeZone(id int64, timeZone string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		user, err := getUser(tx, id)
+		if err != nil {
+			return err
+		}
+		user.TimeZone = timeZone
+		return putUser(tx, *user)
+	})
+}
+
+// GetUserEmail 获取用户邮箱
+func (s *boltStore) GetUserEmail(id int64) string {
+	var email string
+	s.db.View(func(tx *bolt.Tx) error {
+		if u, err := getUser(tx, id); err == nil {
+			email = u.Email
+		}
+		return nil
+	})
+	return email
+}
+
+// UpdateUserEmail 更新用户邮箱
+func (s *boltStore) UpdateUserEmail(id int64, email string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		user, err := getUser(tx, id)
+		if err != nil {
+			return err
+		}
+		user.Email = email
+		return putUser(tx, *user)
+	})
+}
+
+// ========== 健康记录操作 ==========
+
+// CreateBPRecord 创建健康记录
+func (s *boltStore) CreateBPRecord(bp *BloodPressure) (int64, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bp.ID = getNextID(tx, bpBucket)
+		bp.CreatedAt = time.Now()
+		return putBPRecord(tx, *bp)
+	})
+
+	return bp.ID, err
+}
+
+// GetBPRecords 获取记录时间在 [start, end) 内的健康记录，按时间倒序
+func (s *boltStore) GetBPRecords(userID int64, start, end time.Time) ([]BloodPressure, error) {
+	var records []BloodPressure
+	s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bpBucket).Bucket(itob(userID))
+		if b == nil {
+			return nil
+		}
+
+		// 键按秒排序，先按区间定位，再逐条精确比较
+		return scanRange(b, start, end, func(v []byte) error {
+			var bp BloodPressure
+			json.Unmarshal(v, &bp)
+			if inRange(bp.RecordTime, start, end) {
+				records = append(records, bp)
+			}
+			return nil
+		})
+	})
+
+	return records, nil
+}
+
+// GetBPRecord 获取用户的单条健康记录
+func (s *boltStore) GetBPRecord(id, userID int64) (*BloodPressure, error) {
+	var bp *BloodPressure
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, key, owner, ok := findBPRecord(tx, id)
+		if !ok || owner != userID {
+			return fmt.Errorf("record not found")
+		}
+		bp = &BloodPressure{}
+		return json.Unmarshal(b.Get(key), bp)
+	})
+	return bp, err
+}
+
+// UpdateBPRecord 修改健康记录（按ID与所属用户匹配），保留创建时间并记录修改时间
+func (s *boltStore) UpdateBPRecord(bp *BloodPressure) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, key, owner, ok := findBPRecord(tx, bp.ID)
+		if !ok || owner != bp.UserID {
+			return fmt.Errorf("record not found")
+		}
+		var old BloodPressure
+		if err := json.Unmarshal(b.Get(key), &old); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		bp.CreatedAt = old.CreatedAt
+		bp.UpdatedAt = &now
+		return putBPRecord(tx, *bp)
+	})
+}
+
+// DeleteBPRecord 删除血压记录
+func (s *boltStore) DeleteBPRecord(id, userID int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if _, _, owner, ok := findBPRecord(tx, id); !ok || owner != userID {
+			return fmt.Errorf("record not found")
+		}
+		return deleteIndexed(tx, bpBucket, bpIDBucket, id)
+	})
+}
+
+// ========== 通用测量记录 ==========
+
+// CreateMeasurement 创建通用测量记录
+func (s *boltStore) CreateMeasurement(m *Measurement) (int64, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		m.ID = getNextID(tx, measurementsBucket)
+		m.CreatedAt = time.Now()
+		return putMeasurement(tx, *m)
+	})
+	return m.ID, err
+}
+
+// GetMeasurements 获取记录时间在 [start, end) 内的通用测量记录，typ 为空时返回全部类型，按时间倒序
+func (s *boltStore) GetMeasurements(userID int64, typ string, start, end time.Time) ([]Measurement, error) {
+	var list []Measurement
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(measurementsBucket).Bucket(itob(userID))
+		if b == nil {
+			return nil
+		}
+		return scanRange(b, start, end, func(v []byte) error {
+			var m Measurement
+			if err := json.Unmarshal(v, &m); err != nil {
+				return err
+			}
+			if (typ == "" || m.Type == typ) && inRange(m.RecordTime, start, end) {
+				list = append(list, m)
+			}
+			return nil
+		})
+	})
+	return list, err
+}
+
+// GetMeasurement 获取用户的单条通用测量记录
+func (s *boltStore) GetMeasurement(id, userID int64) (*Measurement, error) {
+	var m *Measurement
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, key, owner, ok := findMeasurement(tx, id)
+		if !ok || owner != userID {
+			return fmt.Errorf("record not found")
+		}
+		m = &Measurement{}
+		return json.Unmarshal(b.Get(key), m)
+	})
+	return m, err
+}
+
+// UpdateMeasurement 修改通用测量记录（按ID与所属用户匹配），保留创建时间并记录修改时间
+func (s *boltStore) UpdateMeasurement(m *Measurement) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, key, owner, ok := findMeasurement(tx, m.ID)
+		if !ok || owner != m.UserID {
+			return fmt.Errorf("record not found")
+		}
+		var old Measurement
+		if err := json.Unmarshal(b.Get(key), &old); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		m.CreatedAt = old.CreatedAt
+		m.UpdatedAt = &now
+		return putMeasurement(tx, *m)
+	})
+}
+
+// DeleteMeasurement 删除通用测量记录
+func (s *boltStore) DeleteMeasurement(id, userID int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if _, _, owner, ok := findMeasurement(tx, id); !ok || owner != userID {
+			return fmt.Errorf("record not found")
+		}
+		return deleteIndexed(tx, measurementsBucket, measurementIDBucket, id)
+	})
+}
+
+// ========== 用药 ==========
+
+// CreateMedication 添加药物
+func (s *boltStore) CreateMedication(med *Medication) (int64, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		med.ID = getNextID(tx, medicationsBucket)
+		med.CreatedAt = time.Now()
+		return putMedication(tx, *med)
+	})
+	return med.ID, err
+}
+
+// GetMedications 获取用户的全部药物，按ID顺序
+func (s *boltStore) GetMedications(userID int64) ([]Medication, error) {
+	var list []Medication
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(medicationsBucket).Bucket(itob(userID))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var med Medication
+			if err := json.Unmarshal(v, &med); err != nil {
+				return err
+			}
+			list = append(list, med)
+			return nil
+		})
+	})
+	return list, err
+}
+
+// getMedication 读取用户的单个药物
+func getMedication(tx *bolt.Tx, id, userID int64) (*Medication, error) {
+	b := tx.Bucket(medicationsBucket).Bucket(itob(userID))
+	if b == nil {
+		return nil, fmt.Errorf("medication not found")
+	}
+	data := b.Get(itob(id))
+	if data == nil {
+		return nil, fmt.Errorf("medication not found")
+	}
+	var med Medication
+	if err := json.Unmarshal(data, &med); err != nil {
+		return nil, err
+	}
+	return &med, nil
+}
+
+// GetMedication 获取用户的单个药物
+func (s *boltStore) GetMedication(id, userID int64) (*Medication, error) {
+	var med *Medication
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		med, err = getMedication(tx, id, userID)
+		return err
+	})
+	return med, err
+}
+
+// UpdateMedication 修改药物（按ID与所属用户匹配），保留创建时间并记录修改时间
+func (s *boltStore) UpdateMedication(med *Medication) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		old, err := getMedication(tx, med.ID, med.UserID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		med.CreatedAt = old.CreatedAt
+		med.UpdatedAt = &now
+		return putMedication(tx, *med)
+	})
+}
+
+// DeleteMedication 删除药物，已有的服药记录保留
+func (s *boltStore) DeleteMedication(id, userID int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if _, err := getMedication(tx, id, userID); err != nil {
+			return err
+		}
+		return tx.Bucket(medicationsBucket).Bucket(itob(userID)).Delete(itob(id))
+	})
+}
+
+// CreateIntake 添加服药记录
+func (s *boltStore) CreateIntake(in *MedicationIntake) (int64, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		in.ID = getNextID(tx, intakesBucket)
+		in.CreatedAt = time.Now()
+		return putIntake(tx, *in)
+	})
+	return in.ID, err
+}
+
+// GetIntakes 获取服药时间在 [start, end) 内的服药记录，按时间倒序
+func (s *boltStore) GetIntakes(userID int64, start, end time.Time) ([]MedicationIntake, error) {
+	var list []MedicationIntake
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(intakesBucket).Bucket(itob(userID))
+		if b == nil {
+			return nil
+		}
+		return scanRange(b, start, end, func(v []byte) error {
+			var in MedicationIntake
+			if err := json.Unmarshal(v, &in); err != nil {
+				return err
+			}
+			if inRange(in.TakenAt, start, end) {
+				list = append(list, in)
+			}
+			return nil
+		})
+	})
+	return list, err
+}
+
+// DeleteIntake 删除服药记录
+func (s *boltStore) DeleteIntake(id, userID int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if _, _, owner, ok := findIndexed(tx, intakesBucket, intakeIDBucket, id); !ok || owner != userID {
+			return fmt.Errorf("record not found")
+		}
+		return deleteIndexed(tx, intakesBucket, intakeIDBucket, id)
+	})
+}
+
+// ========== 提醒事件 ==========
+
+// CreateAlert 添加提醒事件
+func (s *boltStore) CreateAlert(a *Alert) (int64, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		a.ID = getNextID(tx, alertsBucket)
+		a.CreatedAt = time.Now()
+		return putAlert(tx, *a)
+	})
+	return a.ID, err
+}
+
+// GetAlerts 获取触发时间在 [start, end) 内的提醒事件，按时间倒序
+func (s *boltStore) GetAlerts(userID int64, start, end time.Time) ([]Alert, error) {
+	var list []Alert
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(alertsBucket).Bucket(itob(userID))
+		if b == nil {
+			return nil
+		}
+		return scanRange(b, start, end, func(v []byte) error {
+			var a Alert
+			if err := json.Unmarshal(v, &a); err != nil {
+				return err
+			}
+			if inRange(a.TriggeredAt, start, end) {
+				list = append(list, a)
+			}
+			return nil
+		})
+	})
+	return list, err
+}
+
+// MarkAlertRead 将提醒事件标记为已读
+func (s *boltStore) MarkAlertRead(id, userID int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, key, owner, ok := findIndexed(tx, alertsBucket, alertIDBucket, id)
+		if !ok || owner != userID {
+			return fmt.Errorf("alert not found")
+		}
+		var a Alert
+		if err := json.Unmarshal(b.Get(key), &a); err != nil {
+			return err
+		}
+		if a.ReadAt != nil {
+			return nil
+		}
+		now := time.Now()
+		a.ReadAt = &now
+		data, _ := json.Marshal(a)
+		return b.Put(key, data)
+	})
+}
+
+// ========== 全局设置 ==========
+
+// GetSetting 获取全局设置
+func (s *boltStore) GetSetting(key string) (string, error) {
+	var value string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metaBucket)
+		v := b.Get([]byte(key))
+		if v != nil {
+			value = string(v)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// SetSetting 保存全局设置
+func (s *boltStore) SetSetting(key, value string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metaBucket)
+		return b.Put([]byte(key), []byte(value))
+	})
+}
+
+// ========== 用户设置 ==========
+
+// GetUserSetting 获取用户设置
+func (s *boltStore) GetUserSetting(userID int64, key string) (string, error) {
+	var value string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(userSettingsBucket).Bucket(itob(userID)); b != nil {
+			value = string(b.Get([]byte(key)))
+		}
+		return nil
+	})
+	return value, err
+}
+
+// SetUserSetting 保存用户设置，value 为空时删除
+func (s *boltStore) SetUserSetting(userID int64, key, value string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if value == "" {
+			if b := tx.Bucket(userSettingsBucket).Bucket(itob(userID)); b != nil {
+				return b.Delete([]byte(key))
+			}
+			return nil
+		}
+		b, err := tx.Bucket(userSettingsBucket).CreateBucketIfNotExists(itob(userID))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), []byte(value))
+	})
+}
+
+// ========== 数据导出导入 ==========
+
+// isInternalMetaKey meta bucket 中除全局设置外还保存了自增序列和结构版本，导出时需跳过
+func isInternalMetaKey(key string) bool {
+	return key == schemaVersionKey || key == string(usersBucket)+"_seq" || key == string(bpBucket)+"_seq" ||
+		key == string(measurementsBucket)+"_seq" || key == string(medicationsBucket)+"_seq" || key == string(intakesBucket)+"_seq" ||
+		key == string(alertsBucket)+"_seq"
+}
+
+// bumpSeq 导入指定ID后，确保自增序列不小于该ID
+func bumpSeq(tx *bolt.Tx, bucket []byte, id int64) error {
+	b := tx.Bucket(metaBucket)
+	key := append(bucket, []byte("_seq")...)
+	var seq int64
+	if val := b.Get(key); val != nil {
+		json.Unmarshal(val, &seq)
+	}
+	if id <= seq {
+		return nil
+	}
+	data, _ := json.Marshal(id)
+	return b.Put(key, data)
+}
+
+// ExportUsers 逐个导出用户（包含密码哈希）
+func (s *boltStore) ExportUsers(fn func(u User) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(usersBucket).ForEach(func(k, v []byte) error {
+			var u User
+			if err := json.Unmarshal(v, &u); err != nil {
+				return err
+			}
+			return fn(u)
+		})
+	})
+}
+
+// ExportBPRecords 逐条导出健康记录（按用户、时间顺序）
+func (s *boltStore) ExportBPRecords(fn func(bp BloodPressure) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		bp := tx.Bucket(bpBucket)
+		return bp.ForEach(func(userID, _ []byte) error {
+			records := bp.Bucket(userID)
+			if records == nil {
+				return nil
+			}
+			return records.ForEach(func(k, v []byte) error {
+				var record BloodPressure
+				if err := json.Unmarshal(v, &record); err != nil {
+					return err
+				}
+				return fn(record)
+			})
+		})
+	})
+}
+
+// ExportMeasurements 逐条导出通用测量记录（按用户、时间顺序）
+func (s *boltStore) ExportMeasurements(fn func(m Measurement) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		parent := tx.Bucket(measurementsBucket)
+		return parent.ForEach(func(userID, _ []byte) error {
+			records := parent.Bucket(userID)
+			if records == nil {
+				return nil
+			}
+			return records.ForEach(func(k, v []byte) error {
+				var m Measurement
+				if err := json.Unmarshal(v, &m); err != nil {
+					return err
+				}
+				return fn(m)
+			})
+		})
+	})
+}
+
+// ExportSettings 逐项导出全局设置
+func (s *boltStore) ExportSettings(fn func(key, value string) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).ForEach(func(k, v []byte) error {
+			if isInternalMetaKey(string(k)) {
+				return nil
+			}
+			return fn(string(k), string(v))
+		})
+	})
+}
+
+// ExportUserSettings 逐项导出用户设置
+func (s *boltStore) ExportUserSettings(fn func(userID int64, key, value string) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		parent := tx.Bucket(userSettingsBucket)
+		return parent.ForEach(func(id, _ []byte) error {
+			b := parent.Bucket(id)
+			if b == nil {
+				return nil
+			}
+			userID := int64(binary.BigEndian.Uint64(id))
+			return b.ForEach(func(k, v []byte) error {
+				return fn(userID, string(k), string(v))
+			})
+		})
+	})
+}
+
+// ExportMedications 逐个导出药物（按用户、ID顺序）
+func (s *boltStore) ExportMedications(fn func(med Medication) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		parent := tx.Bucket(medicationsBucket)
+		return parent.ForEach(func(userID, _ []byte) error {
+			b := parent.Bucket(userID)
+			if b == nil {
+				return nil
+			}
+			return b.ForEach(func(k, v []byte) error {
+				var med Medication
+				if err := json.Unmarshal(v, &med); err != nil {
+					return err
+				}
+				return fn(med)
+			})
+		})
+	})
+}
+
+// ExportIntakes 逐条导出服药记录（按用户、时间顺序）
+func (s *boltStore) ExportIntakes(fn func(in MedicationIntake) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		parent := tx.Bucket(intakesBucket)
+		return parent.ForEach(func(userID, _ []byte) error {
+			records := parent.Bucket(userID)
+			if records == nil {
+				return nil
+			}
+			return records.ForEach(func(k, v []byte) error {
+				var in MedicationIntake
+				if err := json.Unmarshal(v, &in); err != nil {
+					return err
+				}
+				return fn(in)
+			})
+		})
+	})
+}
+
+// ExportAlerts 逐条导出提醒事件（按用户、时间顺序）
+func (s *boltStore) ExportAlerts(fn func(a Alert) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		parent := tx.Bucket(alertsBucket)
+		return parent.ForEach(func(userID, _ []byte) error {
+			records := parent.Bucket(userID)
+			if records == nil {
+				return nil
+			}
+			return records.ForEach(func(k, v []byte) error {
+				var a Alert
+				if err := json.Unmarshal(v, &a); err != nil {
+					return err
+				}
+				return fn(a)
+			})
+		})
+	})
+}
+
+// ImportUser 按原ID写入用户
+func (s *boltStore) ImportUser(u User) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := putUser(tx, u); err != nil {
+			return err
+		}
+		return bumpSeq(tx, usersBucket, u.ID)
+	})
+}
+
+// ImportBPRecord 按原ID写入健康记录
+func (s *boltStore) ImportBPRecord(bp BloodPressure) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := putBPRecord(tx, bp); err != nil {
+			return err
+		}
+		return bumpSeq(tx, bpBucket, bp.ID)
+	})
+}
+
+// ImportMeasurement 按原ID写入通用测量记录
+func (s *boltStore) ImportMeasurement(m Measurement) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := putMeasurement(tx, m); err != nil {
+			return err
+		}
+		return bumpSeq(tx, measurementsBucket, m.ID)
+	})
+}
+
+// ImportMedication 按原ID写入药物
+func (s *boltStore) ImportMedication(med Medication) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := putMedication(tx, med); err != nil {
+			return err
+		}
+		return bumpSeq(tx, medicationsBucket, med.ID)
+	})
+}
+
+// ImportIntake 按原ID写入服药记录
+func (s *boltStore) ImportIntake(in MedicationIntake) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := putIntake(tx, in); err != nil {
+			return err
+		}
+		return bumpSeq(tx, intakesBucket, in.ID)
+	})
+}
+
+// ImportAlert 按原ID写入提醒事件
+func (s *boltStore) ImportAlert(a Alert) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := putAlert(tx, a); err != nil {
+			return err
+		}
+		return bumpSeq(tx, alertsBucket, a.ID)
+	})
+}
+
+// ClearData 清空用户、健康记录、通用测量记录、用药数据、提醒事件、索引、全局设置和用户设置
+func (s *boltStore) ClearData() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		buckets := [][]byte{usersBucket, usersByNameBucket, usersByRoleBucket, bpBucket, bpIDBucket,
+			measurementsBucket, measurementIDBucket, userSettingsBucket, medicationsBucket, intakesBucket, intakeIDBucket,
+			alertsBucket, alertIDBucket}
+		for _, name := range buckets {
+			if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+
+		// 删除设置与自增序列，只保留结构版本
+		meta := tx.Bucket(metaBucket)
+		var keys [][]byte
+		meta.ForEach(func(k, v []byte) error {
+			if string(k) != schemaVersionKey {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range keys {
+			if err := meta.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ========== 数据库备份 ==========
+
+// Backup 将数据库一致性快照写入w
+func (s *boltStore) Backup(w io.Writer) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(w)
+		return err
+	})
+}
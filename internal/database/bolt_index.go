@@ -0,0 +1,356 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// bolt 数据布局（结构版本3起）：
+//
+//	users                 用户ID(十进制) -> 用户JSON
+//	users_by_name         用户名 -> 用户ID(十进制)
+//	users_by_role         角色(嵌套bucket) -> 用户ID(十进制) -> 空
+//	blood_pressure        用户ID(8字节大端, 嵌套bucket) -> 记录时间(8字节) + 记录ID(8字节) -> 记录JSON
+//	blood_pressure_ids    记录ID(8字节大端) -> 用户ID(8字节) + 记录键(16字节)
+//	measurements          与 blood_pressure 结构相同，保存通用测量记录（结构版本6起）
+//	measurement_ids       与 blood_pressure_ids 结构相同
+//	user_settings         用户ID(8字节大端, 嵌套bucket) -> 设置键 -> 设置值（结构版本7起）
+//	medications           用户ID(8字节大端, 嵌套bucket) -> 药物ID(8字节) -> 药物JSON（结构版本8起）
+//	medication_intakes    与 blood_pressure 结构相同，按服药时间保存服药记录（结构版本8起）
+//	medication_intake_ids 与 blood_pressure_ids 结构相同
+//	alerts                与 blood_pressure 结构相同，按触发时间保存提醒事件（结构版本9起）
+//	alert_ids             与 blood_pressure_ids 结构相同
+//
+// 同一用户的记录按时间有序存放，按日期查询时只需区间扫描。
+var (
+	usersByNameBucket   = []byte("users_by_name")
+	usersByRoleBucket   = []byte("users_by_role")
+	bpIDBucket          = []byte("blood_pressure_ids")
+	measurementsBucket  = []byte("measurements")
+	measurementIDBucket = []byte("measurement_ids")
+	userSettingsBucket  = []byte("user_settings")
+	medicationsBucket   = []byte("medications")
+	intakesBucket       = []byte("medication_intakes")
+	intakeIDBucket      = []byte("medication_intake_ids")
+	alertsBucket        = []byte("alerts")
+	alertIDBucket       = []byte("alert_ids")
+)
+
+// itob 将ID编码为8字节大端序
+func itob(v int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	return b
+}
+
+// userKey users bucket 中的键
+func userKey(id int64) []byte {
+	return []byte(strconv.FormatInt(id, 10))
+}
+
+// timeKey 将时间编码为8字节，字节序与时间先后一致（翻转符号位以兼容1970年以前的时间）
+func timeKey(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.Unix())^(1<<63))
+	return b
+}
+
+// recordKey 记录在用户bucket中的键：记录时间 + 记录ID
+func recordKey(t time.Time, id int64) []byte {
+	return append(timeKey(t), itob(id)...)
+}
+
+// ========== 用户索引 ==========
+
+// putUser 写入用户并维护用户名、角色索引
+func putUser(tx *bolt.Tx, u User) error {
+	key := userKey(u.ID)
+	users := tx.Bucket(usersBucket)
+	if data := users.Get(key); data != nil {
+		var old User
+		if err := json.Unmarshal(data, &old); err == nil {
+			if err := unindexUser(tx, old); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, _ := json.Marshal(u)
+	if err := users.Put(key, data); err != nil {
+		return err
+	}
+	if err := tx.Bucket(usersByNameBucket).Put([]byte(u.Username), key); err != nil {
+		return err
+	}
+	if u.Role == "" {
+		return nil
+	}
+	role, err := tx.Bucket(usersByRoleBucket).CreateBucketIfNotExists([]byte(u.Role))
+	if err != nil {
+		return err
+	}
+	return role.Put(key, []byte{})
+}
+
+// unindexUser 删除用户的用户名、角色索引
+func unindexUser(tx *bolt.Tx, u User) error {
+	if err := tx.Bucket(usersByNameBucket).Delete([]byte(u.Username)); err != nil {
+		return err
+	}
+	if role := tx.Bucket(usersByRoleBucket).Bucket([]byte(u.Role)); role != nil {
+		return role.Delete(userKey(u.ID))
+	}
+	return nil
+}
+
+// getUser 按ID读取用户
+func getUser(tx *bolt.Tx, id int64) (*User, error) {
+	data := tx.Bucket(usersBucket).Get(userKey(id))
+	if data == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	var u User
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ========== 按用户、时间分组的记录索引 ==========
+
+// putIndexed 将记录写入所属用户的bucket并更新ID索引，同ID的旧记录会被替换
+func putIndexed(tx *bolt.Tx, bucket, idBucket []byte, userID, id int64, t time.Time, data []byte) error {
+	if err := deleteIndexed(tx, bucket, idBucket, id); err != nil {
+		return err
+	}
+
+	b, err := tx.Bucket(bucket).CreateBucketIfNotExists(itob(userID))
+	if err != nil {
+		return err
+	}
+	key := recordKey(t, id)
+	if err := b.Put(key, data); err != nil {
+		return err
+	}
+	return tx.Bucket(idBucket).Put(itob(id), append(itob(userID), key...))
+}
+
+// findIndexed 通过ID索引定位记录，不存在时返回 ok=false
+func findIndexed(tx *bolt.Tx, bucket, idBucket []byte, id int64) (b *bolt.Bucket, key []byte, userID int64, ok bool) {
+	loc := tx.Bucket(idBucket).Get(itob(id))
+	if len(loc) != 24 {
+		return nil, nil, 0, false
+	}
+	b = tx.Bucket(bucket).Bucket(loc[:8])
+	if b == nil {
+		return nil, nil, 0, false
+	}
+	key = append([]byte(nil), loc[8:]...)
+	return b, key, int64(binary.BigEndian.Uint64(loc[:8])), true
+}
+
+// deleteIndexed 按ID删除记录及其索引，记录不存在时不做任何操作
+func deleteIndexed(tx *bolt.Tx, bucket, idBucket []byte, id int64) error {
+	b, key, _, ok := findIndexed(tx, bucket, idBucket, id)
+	if !ok {
+		return nil
+	}
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+	return tx.Bucket(idBucket).Delete(itob(id))
+}
+
+// deleteUserIndexed 删除用户的全部记录及ID索引
+func deleteUserIndexed(tx *bolt.Tx, bucket, idBucket []byte, userID int64) error {
+	parent := tx.Bucket(bucket)
+	records := parent.Bucket(itob(userID))
+	if records == nil {
+		return nil
+	}
+	ids := tx.Bucket(idBucket)
+	err := records.ForEach(func(k, v []byte) error {
+		return ids.Delete(k[8:])
+	})
+	if err != nil {
+		return err
+	}
+	return parent.DeleteBucket(itob(userID))
+}
+
+// scanRange 按时间倒序遍历用户bucket中记录时间在 [start, end) 所在秒区间内的记录，零值表示不限。
+// 键只精确到秒，调用方需再按记录时间精确比较。
+func scanRange(b *bolt.Bucket, start, end time.Time, fn func(v []byte) error) error {
+	var min []byte
+	if !start.IsZero() {
+		min = timeKey(start)
+	}
+	c := b.Cursor()
+	k, v := c.Last()
+	if !end.IsZero() {
+		if k, v = c.Seek(timeKey(end)); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+	}
+
+	for ; k != nil && bytes.Compare(k, min) >= 0; k, v = c.Prev() {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inRange 判断时间是否在 [start, end) 内，零值表示不限
+func inRange(t, start, end time.Time) bool {
+	if !start.IsZero() && t.Before(start) {
+		return false
+	}
+	return end.IsZero() || t.Before(end)
+}
+
+// putBPRecord 写入健康记录并更新ID索引
+func putBPRecord(tx *bolt.Tx, bp BloodPressure) error {
+	data, _ := json.Marshal(bp)
+	return putIndexed(tx, bpBucket, bpIDBucket, bp.UserID, bp.ID, bp.RecordTime, data)
+}
+
+// findBPRecord 通过ID索引定位健康记录
+func findBPRecord(tx *bolt.Tx, id int64) (b *bolt.Bucket, key []byte, userID int64, ok bool) {
+	return findIndexed(tx, bpBucket, bpIDBucket, id)
+}
+
+// putMeasurement 写入通用测量记录并更新ID索引
+func putMeasurement(tx *bolt.Tx, m Measurement) error {
+	data, _ := json.Marshal(m)
+	return putIndexed(tx, measurementsBucket, measurementIDBucket, m.UserID, m.ID, m.RecordTime, data)
+}
+
+// findMeasurement 通过ID索引定位通用测量记录
+func findMeasurement(tx *bolt.Tx, id int64) (b *bolt.Bucket, key []byte, userID int64, ok bool) {
+	return findIndexed(tx, measurementsBucket, measurementIDBucket, id)
+}
+
+// putMedication 将药物写入所属用户的bucket
+func putMedication(tx *bolt.Tx, med Medication) error {
+	b, err := tx.Bucket(medicationsBucket).CreateBucketIfNotExists(itob(med.UserID))
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(med)
+	return b.Put(itob(med.ID), data)
+}
+
+// putIntake 写入服药记录并更新ID索引
+func putIntake(tx *bolt.Tx, in MedicationIntake) error {
+	data, _ := json.Marshal(in)
+	return putIndexed(tx, intakesBucket, intakeIDBucket, in.UserID, in.ID, in.TakenAt, data)
+}
+
+// putAlert 写入提醒事件并更新ID索引
+func putAlert(tx *bolt.Tx, a Alert) error {
+	data, _ := json.Marshal(a)
+	return putIndexed(tx, alertsBucket, alertIDBucket, a.UserID, a.ID, a.TriggeredAt, data)
+}
+
+// ========== 结构迁移 ==========
+
+// boltIndexData 建立用户名、角色索引，并将平铺存放的健康记录重建为按用户、时间分组的结构
+func boltIndexData(tx *bolt.Tx) error {
+	for _, name := range [][]byte{usersByNameBucket, usersByRoleBucket, bpIDBucket} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+
+	var users []User
+	err := tx.Bucket(usersBucket).ForEach(func(k, v []byte) error {
+		var u User
+		if err := json.Unmarshal(v, &u); err != nil {
+			return fmt.Errorf("用户 %s 数据损坏: %v", k, err)
+		}
+		users = append(users, u)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := putUser(tx, u); err != nil {
+			return err
+		}
+	}
+
+	// 旧版记录以十进制ID为键平铺在 blood_pressure 中，读出后重建该bucket
+	var records []BloodPressure
+	err = tx.Bucket(bpBucket).ForEach(func(k, v []byte) error {
+		if v == nil {
+			return nil
+		}
+		var bp BloodPressure
+		if err := json.Unmarshal(v, &bp); err != nil {
+			return fmt.Errorf("健康记录 %s 数据损坏: %v", k, err)
+		}
+		records = append(records, bp)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.DeleteBucket(bpBucket); err != nil {
+		return err
+	}
+	if _, err := tx.CreateBucket(bpBucket); err != nil {
+		return err
+	}
+	for _, bp := range records {
+		if err := putBPRecord(tx, bp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// boltCreateMeasurementBuckets 创建通用测量记录的bucket
+func boltCreateMeasurementBuckets(tx *bolt.Tx) error {
+	for _, name := range [][]byte{measurementsBucket, measurementIDBucket} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// boltCreateUserSettingsBucket 创建用户设置bucket
+func boltCreateUserSettingsBucket(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(userSettingsBucket)
+	return err
+}
+
+// boltCreateMedicationBuckets 创建药物与服药记录的bucket
+func boltCreateMedicationBuckets(tx *bolt.Tx) error {
+	for _, name := range [][]byte{medicationsBucket, intakesBucket, intakeIDBucket} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// boltCreateAlertBuckets 创建提醒事件的bucket
+func boltCreateAlertBuckets(tx *bolt.Tx) error {
+	for _, name := range [][]byte{alertsBucket, alertIDBucket} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// 加密备份格式：
+//
+//	文件头：magic(8) | 版本(1) | scrypt logN(1) | r(1) | p(1) | salt(16) | nonce前缀(7)
+//	数据块：AES-256-GCM 分块加密，每块明文最多64KB
+//
+// 密钥由口令经 scrypt 派生。每块的 nonce 为 nonce前缀 + 块序号(4) + 结尾标记(1)，
+// 文件头作为附加认证数据，因此块被截断、重排或文件头被篡改都会导致解密失败。
+const (
+	encMagic      = "HMENCBK\x00"
+	encVersion    = 1
+	encSaltSize   = 16
+	encPrefixSize = 7
+	encHeaderSize = len(encMagic) + 4 + encSaltSize + encPrefixSize
+	encChunkSize  = 64 * 1024
+
+	// scrypt 参数（N=2^15, r=8, p=1）。读取时 r、p 必须与此相同，logN 不超过 encMaxLogN，
+	// 内存与计算量随 N·r·p 增长，防止恶意文件通过过大的参数消耗资源
+	encLogN    = 15
+	encR       = 8
+	encP       = 1
+	encMaxLogN = 20
+)
+
+// ErrPassphraseRequired 备份文件已加密但未提供口令
+var ErrPassphraseRequired = errors.New("备份文件已加密，请提供密码")
+
+// errDecrypt 口令错误或数据被篡改
+var errDecrypt = errors.New("密码错误或备份文件已损坏")
+
+// isEncryptedBackup 判断文件是否为加密备份
+func isEncryptedBackup(path string) (bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, fmt.Errorf("备份文件不存在: %s", path)
+	}
+	if err != nil {
+		return false, fmt.Errorf("无法打开备份文件: %v", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(encMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false, nil
+	}
+	return string(magic) == encMagic, nil
+}
+
+// newBackupCipher 由口令和文件头中的参数派生 AES-256-GCM 密钥
+func newBackupCipher(passphrase string, header []byte) (cipher.AEAD, error) {
+	logN, r, p := header[len(encMagic)+1], header[len(encMagic)+2], header[len(encMagic)+3]
+	if logN == 0 || logN > encMaxLogN || r != encR || p != encP {
+		return nil, fmt.Errorf("加密参数无效")
+	}
+	salt := header[len(encMagic)+4 : len(encMagic)+4+encSaltSize]
+
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<logN, int(r), int(p), 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce 生成第n块的 nonce
+func chunkNonce(header []byte, n uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, header[encHeaderSize-encPrefixSize:])
+	binary.BigEndian.PutUint32(nonce[encPrefixSize:], n)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptWriter 将写入的数据分块加密后写入底层 Writer，必须调用 Close 写出最后一块
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	buf    []byte
+	n      uint32
+}
+
+// NewEncryptWriter 写出文件头并返回用口令加密的 Writer，关闭时写出最后一块（不会关闭w）
+func NewEncryptWriter(w io.Writer, passphrase string) (io.WriteCloser, error) {
+	header := make([]byte, encHeaderSize)
+	copy(header, encMagic)
+	header[len(encMagic)] = encVersion
+	header[len(encMagic)+1] = encLogN
+	header[len(encMagic)+2] = encR
+	header[len(encMagic)+3] = encP
+	if _, err := rand.Read(header[len(encMagic)+4:]); err != nil {
+		return nil, err
+	}
+
+	aead, err := newBackupCipher(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, header: header, buf: make([]byte, 0, encChunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// 缓冲区已满且还有数据，说明当前块不是最后一块
+		if len(e.buf) == encChunkSize {
+			if err := e.flush(false); err != nil {
+				return written, err
+			}
+		}
+		k := copy(e.buf[len(e.buf):encChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+k]
+		p = p[k:]
+		written += k
+	}
+	return written, nil
+}
+
+// Close 写出最后一块（可能为空）
+func (e *encryptWriter) Close() error {
+	return e.flush(true)
+}
+
+func (e *encryptWriter) flush(last bool) error {
+	out := e.aead.Seal(nil, chunkNonce(e.header, e.n, last), e.buf, e.header)
+	e.n++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(out)
+	return err
+}
+
+// decryptReader 逐块解密加密备份
+type decryptReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	buf    []byte
+	plain  []byte
+	n      uint32
+	done   bool
+}
+
+// newDecryptReader 读取文件头、派生密钥并返回解密 Reader
+func newDecryptReader(r io.Reader, passphrase string) (io.Reader, error) {
+	header := make([]byte, encHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header[:len(encMagic)], []byte(encMagic)) {
+		return nil, fmt.Errorf("不是有效的加密备份文件")
+	}
+	if header[len(encMagic)] > encVersion {
+		return nil, fmt.Errorf("加密备份格式版本(%d)高于当前程序支持的版本(%d)", header[len(encMagic)], encVersion)
+	}
+
+	aead, err := newBackupCipher(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:      bufio.NewReaderSize(r, encChunkSize+aead.Overhead()+1),
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, encChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next 读取并解密下一块；读满一块后再向后探测一个字节，以判断是否为最后一块
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.r, d.buf)
+	last := false
+	switch err {
+	case nil:
+		if _, err := d.r.Peek(1); err == io.EOF {
+			last = true
+		}
+	case io.ErrUnexpectedEOF:
+		last = true
+	case io.EOF:
+		return errDecrypt
+	default:
+		return err
+	}
+
+	plain, err := d.aead.Open(d.buf[:0], chunkNonce(d.header, d.n, last), d.buf[:n], d.header)
+	if err != nil {
+		return errDecrypt
+	}
+	d.n++
+	d.plain = plain
+	d.done = last
+	return nil
+}
+
+// decryptBackupFile 将加密备份解密到临时文件，返回临时文件路径，调用方负责删除
+func decryptBackupFile(srcPath, passphrase, dir string) (string, error) {
+	if passphrase == "" {
+		return "", ErrPassphraseRequired
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("无法打开备份文件: %v", err)
+	}
+	defer src.Close()
+
+	r, err := newDecryptReader(src, passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(dir, "restore-*.plain")
+	if err != nil {
+		return "", fmt.Errorf("无法创建暂存文件: %v", err)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encrypt 用口令加密 plain，返回完整的加密备份内容
+func encrypt(t *testing.T, plain []byte, passphrase string) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	w, err := NewEncryptWriter(&out, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+// decrypt 解密加密备份内容
+func decrypt(data []byte, passphrase string) ([]byte, error) {
+	r, err := newDecryptReader(bytes.NewReader(data), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, 1000, encChunkSize, encChunkSize + 1, 3*encChunkSize + 17} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		data := encrypt(t, plain, "correct horse")
+		if !bytes.HasPrefix(data, []byte(encMagic)) {
+			t.Fatalf("size %d: missing magic", size)
+		}
+		got, err := decrypt(data, "correct horse")
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("size %d: decrypted data differs", size)
+		}
+	}
+}
+
+func TestDecryptWrongPassphrase(t *testing.T) {
+	data := encrypt(t, []byte(`{"type":"header"}`), "correct horse")
+	if _, err := decrypt(data, "wrong"); !errors.Is(err, errDecrypt) {
+		t.Fatalf("err = %v, want %v", err, errDecrypt)
+	}
+}
+
+func TestDecryptTruncated(t *testing.T) {
+	plain := make([]byte, 2*encChunkSize+100)
+	rand.Read(plain)
+	data := encrypt(t, plain, "pw")
+	chunk := encChunkSize + 16 // 每块附加16字节认证标签
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"只有文件头", encHeaderSize},
+		{"截断最后一块", len(data) - 10},
+		{"缺少最后一块", encHeaderSize + 2*chunk},
+		{"只剩第一块", encHeaderSize + chunk},
+		{"截断第一块", encHeaderSize + chunk/2},
+	}
+	for _, tt := range tests {
+		if _, err := decrypt(data[:tt.size], "pw"); !errors.Is(err, errDecrypt) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, errDecrypt)
+		}
+	}
+	if _, err := decrypt(data[:encHeaderSize-1], "pw"); err == nil {
+		t.Error("incomplete header: no error")
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	plain := make([]byte, encChunkSize+100)
+	rand.Read(plain)
+	data := encrypt(t, plain, "pw")
+
+	for _, pos := range []int{
+		len(encMagic),      // 版本
+		len(encMagic) + 4,  // salt
+		encHeaderSize - 1,  // nonce 前缀
+		encHeaderSize,      // 第一块密文
+		encHeaderSize + 42, // 第一块密文
+		len(data) - 1,      // 最后一块的认证标签
+	} {
+		tampered := append([]byte{}, data...)
+		tampered[pos] ^= 0x01
+		if got, err := decrypt(tampered, "pw"); err == nil {
+			t.Errorf("byte %d flipped: decrypted %d bytes without error", pos, len(got))
+		}
+	}
+}
+
+func TestDecryptRejectsCostlyParameters(t *testing.T) {
+	data := encrypt(t, []byte("x"), "pw")
+	for _, tt := range []struct {
+		offset int
+		value  byte
+	}{
+		{1, 0},
+		{1, encMaxLogN + 1},
+		{2, encR + 1},
+		{2, 255},
+		{3, encP + 1},
+		{3, 255},
+	} {
+		tampered := append([]byte{}, data...)
+		tampered[len(encMagic)+tt.offset] = tt.value
+		if _, err := newDecryptReader(bytes.NewReader(tampered), "pw"); err == nil || errors.Is(err, errDecrypt) {
+			t.Errorf("header byte %d = %d: err = %v, want invalid parameters", tt.offset, tt.value, err)
+		}
+	}
+}
+
+func TestDecryptBackupFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "backup.enc")
+	plain := []byte("{\"type\":\"header\"}\n")
+	if err := os.WriteFile(src, encrypt(t, plain, "pw"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := isEncryptedBackup(src); !ok || err != nil {
+		t.Fatalf("isEncryptedBackup = %v, %v", ok, err)
+	}
+	if _, err := decryptBackupFile(src, "", dir); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("empty passphrase: err = %v, want %v", err, ErrPassphraseRequired)
+	}
+	if _, err := decryptBackupFile(src, "wrong", dir); !errors.Is(err, errDecrypt) {
+		t.Errorf("wrong passphrase: err = %v, want %v", err, errDecrypt)
+	}
+
+	path, err := decryptBackupFile(src, "pw", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path)
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, plain) {
+		t.Errorf("decrypted file = %q, want %q", got, plain)
+	}
+
+	// 失败时不留下暂存文件
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("dir has %d entries, want backup and decrypted file", len(entries))
+	}
+}
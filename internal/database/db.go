@@ -2,755 +2,470 @@ package database
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"os"
-	"sort"
 	"sync"
 	"time"
 
 	"health-manager/internal/config"
 
-	_ "github.com/go-sql-driver/mysql"
-	bolt "go.etcd.io/bbolt"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var (
-	sqlDB    *sql.DB
-	boltDB   *bolt.DB
-	dbMux    sync.Mutex
-	usingSQL bool
-)
+// Manager 持有当前使用的存储后端，支持运行时切换数据库。
+// Manager 本身实现了 Store，供 handlers 和中间件注入使用。
+type Manager struct {
+	mu    sync.RWMutex
+	store Store
+	cfg   *config.DBConfig
+}
 
-// User 用户结构
-type User struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"password"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
-// BloodPressure 健康记录结构（包含血压和身高体重）
-type BloodPressure struct {
-	ID         int64     `json:"id"`
-	UserID     int64     `json:"user_id"`
-	Systolic   int       `json:"systolic"`
-	Diastolic  int       `json:"diastolic"`
-	HeartRate  int       `json:"heart_rate"`
-	Height     float64   `json:"height"`
-	Weight     float64   `json:"weight"`
-	Waistline  float64   `json:"waistline"`
-	RecordTime time.Time `json:"record_time"`
-	Notes      string    `json:"notes"`
-	CreatedAt  time.Time `json:"created_at"`
-}
-
-var (
-	usersBucket = []byte("users")
-	bpBucket    = []byte("blood_pressure")
-	metaBucket  = []byte("meta")
-)
+// NewManager 使用已打开的存储创建Manager
+func NewManager(store Store, cfg *config.DBConfig) *Manager {
+	return &Manager{store: store, cfg: cfg}
+}
 
-// InitDB 初始化数据库
-func InitDB() error {
+// InitDB 根据配置文件初始化数据库
+func InitDB() (*Manager, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if cfg.Type == "mysql" {
-		return connectMySQL(cfg)
-	}
-	return connectBolt()
-}
-
-// connectBolt 连接Bolt数据库
-func connectBolt() error {
-	dbPath := "data/health_manager.db"
-	oldDbPath := "data/blood_pressure.db"
-	legacyDbPath := "data/blood_manager.db"
-
-	// 自动迁移：如果新数据库不存在但旧数据库存在，则重命名
-	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		if _, err := os.Stat(legacyDbPath); err == nil {
-			log.Printf("发现旧版数据库 %s，正在重命名为 %s...", legacyDbPath, dbPath)
-			os.Rename(legacyDbPath, dbPath)
-		} else if _, err := os.Stat(oldDbPath); err == nil {
-			log.Printf("发现旧版数据库 %s，正在重命名为 %s...", oldDbPath, dbPath)
-			os.Rename(oldDbPath, dbPath)
-		}
+	cfg = normalizeLegacyType(cfg)
+	store, err := Open(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return NewManager(store, cfg), nil
+}
 
-	var err error
-	boltDB, err = bolt.Open(dbPath, 0600, nil)
+// Open 根据配置打开对应的存储后端，空库时创建默认管理员
+func Open(cfg *config.DBConfig) (Store, error) {
+	store, err := connect(cfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	usingSQL = false
 
-	// 创建buckets
-	err = boltDB.Update(func(tx *bolt.Tx) error {
-		if _, err := tx.CreateBucketIfNotExists(usersBucket); err != nil {
-			return err
-		}
-		if _, err := tx.CreateBucketIfNotExists(bpBucket); err != nil {
-			return err
-		}
-		if _, err := tx.CreateBucketIfNotExists(metaBucket); err != nil {
-			return err
-		}
-		return nil
-	})
-	if err != nil {
-		return err
+	if err := createDefaultAdmin(store); err != nil {
+		store.Close()
+		return nil, err
 	}
+	return store, nil
+}
 
-	// 创建默认管理员
-	return createDefaultAdmin()
+// connect 连接存储后端并执行结构迁移，不写入任何数据
+func connect(cfg *config.DBConfig) (Store, error) {
+	switch cfg.Type {
+	case config.TypeMySQL:
+		return connectMySQL(cfg)
+	case config.TypePostgres:
+		return connectPostgres(cfg)
+	case config.TypeSQLite:
+		return connectSQLite()
+	default:
+		return connectBolt()
+	}
 }
 
-func createDefaultAdmin() error {
+func createDefaultAdmin(store Store) error {
 	// 只在数据库中没有任何用户时创建默认管理员
-	users, _ := GetAllUsers()
+	users, _ := store.GetAllUsers()
 	if len(users) > 0 {
 		return nil // 已有用户，不创建默认账户
 	}
 
 	// 创建默认管理员账户 admin/admin123
 	hashedPwd, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
-	return CreateUser("admin", string(hashedPwd), "admin")
+	return store.CreateUser("admin", string(hashedPwd), "admin")
 }
 
-// connectMySQL 连接MySQL
-func connectMySQL(cfg *config.DBConfig) error {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName)
-
-	var err error
-	sqlDB, err = sql.Open("mysql", dsn)
-	if err != nil {
-		return err
+// TestConnection 测试MySQL/PostgreSQL连接
+func TestConnection(cfg *config.DBConfig) error {
+	var (
+		testDB *sql.DB
+		err    error
+	)
+	switch cfg.Type {
+	case config.TypeMySQL:
+		testDB, err = sql.Open("mysql", mysqlDSN(cfg))
+	case config.TypePostgres:
+		testDB, err = sql.Open("pgx", postgresDSN(cfg))
+	default:
+		return nil
 	}
-
-	if err = sqlDB.Ping(); err != nil {
+	if err != nil {
 		return err
 	}
-
-	usingSQL = true
-	return createMySQLTables()
+	defer testDB.Close()
+	return testDB.Ping()
 }
 
-func createMySQLTables() error {
-	userTable := `CREATE TABLE IF NOT EXISTS users (
-		id BIGINT PRIMARY KEY AUTO_INCREMENT,
-		username VARCHAR(50) UNIQUE NOT NULL,
-		password VARCHAR(255) NOT NULL,
-		role VARCHAR(20) DEFAULT 'user',
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	)`
-	if _, err := sqlDB.Exec(userTable); err != nil {
-		return err
-	}
+// current 返回当前存储
+func (m *Manager) current() Store {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.store
+}
 
-	bpTable := `CREATE TABLE IF NOT EXISTS blood_pressure (
-		id BIGINT PRIMARY KEY AUTO_INCREMENT,
-		user_id BIGINT NOT NULL,
-		systolic INT,
-		diastolic INT,
-		heart_rate INT,
-		height DECIMAL(5,2),
-		weight DECIMAL(5,2),
-		waistline DECIMAL(5,2),
-		record_time DATETIME NOT NULL,
-		notes TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	)`
-	if _, err := sqlDB.Exec(bpTable); err != nil {
-		return err
-	}
+// Config 返回当前数据库配置
+func (m *Manager) Config() *config.DBConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cfg
+}
 
-	// 自动迁移：为 MySQL 旧表添加缺失字段
-	if usingSQL {
-		columnsToEnsure := []struct {
-			name string
-			spec string
-		}{
-			{"height", "DECIMAL(5,2) DEFAULT 0"},
-			{"weight", "DECIMAL(5,2) DEFAULT 0"},
-			{"waistline", "DECIMAL(5,2) DEFAULT 0"},
-		}
+// SwitchDB 切换数据库
+func (m *Manager) SwitchDB(cfg *config.DBConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-		for _, col := range columnsToEnsure {
-			// 检查列是否存在
-			var count int
-			err := sqlDB.QueryRow(`SELECT COUNT(*) FROM information_schema.columns 
-				WHERE table_schema = DATABASE() AND table_name = 'blood_pressure' AND column_name = ?`, col.name).Scan(&count)
-			if err == nil && count == 0 {
-				// 列不存在，添加它
-				log.Printf("正在迁移数据库：为 blood_pressure 表添加 %s 字段", col.name)
-				_, execErr := sqlDB.Exec(fmt.Sprintf("ALTER TABLE blood_pressure ADD COLUMN %s %s", col.name, col.spec))
-				if execErr != nil {
-					log.Printf("迁移字段 %s 失败: %v", col.name, execErr)
-				}
-			}
-		}
+	// 仍是同一个本地文件数据库时沿用当前连接（bolt 有文件锁，不能在关闭前再次打开）
+	if m.store != nil && m.cfg != nil && sameDatabase(m.cfg, cfg) &&
+		cfg.Type != config.TypeMySQL && cfg.Type != config.TypePostgres {
+		m.cfg = cfg
+		return config.SetConfig(cfg)
 	}
 
-	// 创建默认管理员
-	// 只在数据库中没有任何用户时创建默认 admin 账户
-	var userCount int
-	sqlDB.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount)
-	if userCount == 0 {
-		hashedPwd, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
-		sqlDB.Exec("INSERT INTO users (username, password, role) VALUES (?, ?, ?)",
-			"admin", string(hashedPwd), "admin")
-	}
-	settingsTable := `CREATE TABLE IF NOT EXISTS settings (
-		description VARCHAR(100),
-		setting_key VARCHAR(50) PRIMARY KEY,
-		setting_value TEXT
-	)`
-	if _, err := sqlDB.Exec(settingsTable); err != nil {
+	// 先打开新连接，失败时原连接保持可用
+	store, err := Open(cfg)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	if m.store != nil {
+		m.store.Close()
+	}
+	m.store = store
+	m.cfg = cfg
+	return config.SetConfig(cfg)
 }
 
-// TestConnection 测试MySQL连接
-func TestConnection(cfg *config.DBConfig) error {
-	if cfg.Type != "mysql" {
+// Close 关闭当前存储
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store == nil {
 		return nil
 	}
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName)
-	testDB, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return err
+	err := m.store.Close()
+	m.store = nil
+	return err
+}
+
+// ========== 数据库备份还原 ==========
+
+// Backup 将当前数据库文件快照写入w（仅bolt支持）
+func (m *Manager) Backup(w io.Writer) error {
+	store := m.current()
+	if store == nil {
+		return fmt.Errorf("数据库未初始化")
 	}
-	defer testDB.Close()
-	return testDB.Ping()
+	return store.Backup(w)
 }
 
-// SwitchDB 切换数据库
-func SwitchDB(cfg *config.DBConfig) error {
-	dbMux.Lock()
-	defer dbMux.Unlock()
-
-	// 关闭旧连接
-	if boltDB != nil {
-		boltDB.Close()
-		boltDB = nil
+// ExportBackup 将当前数据库导出为与后端无关的逻辑备份
+func (m *Manager) ExportBackup(w io.Writer) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.store == nil {
+		return fmt.Errorf("数据库未初始化")
 	}
-	if sqlDB != nil {
-		sqlDB.Close()
-		sqlDB = nil
+	return ExportBackup(m.store, m.cfg.Type, w)
+}
+
+// BackupDB 以逻辑备份格式备份数据库到指定路径，passphrase 非空时用其加密备份
+func (m *Manager) BackupDB(destPath, passphrase string) error {
+	destFile, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("无法创建备份文件: %v", err)
 	}
+	defer destFile.Close()
 
-	var err error
-	if cfg.Type == "mysql" {
-		err = connectMySQL(cfg)
-	} else {
-		err = connectBolt()
+	if passphrase == "" {
+		if err := m.ExportBackup(destFile); err != nil {
+			return err
+		}
+		return destFile.Close()
 	}
 
+	enc, err := NewEncryptWriter(destFile, passphrase)
 	if err != nil {
+		return fmt.Errorf("无法加密备份: %v", err)
+	}
+	if err := m.ExportBackup(enc); err != nil {
 		return err
 	}
-
-	return config.SetConfig(cfg)
-}
-
-// ========== 用户操作 ==========
-
-func getNextID(tx *bolt.Tx, bucket []byte) int64 {
-	b := tx.Bucket(metaBucket)
-	key := append(bucket, []byte("_seq")...)
-	val := b.Get(key)
-	var id int64 = 1
-	if val != nil {
-		json.Unmarshal(val, &id)
-		id++
+	if err := enc.Close(); err != nil {
+		return err
 	}
-	data, _ := json.Marshal(id)
-	b.Put(key, data)
-	return id
+	return destFile.Close()
 }
 
-// GetUserByUsername 根据用户名获取用户
-func GetUserByUsername(username string) (*User, error) {
-	if usingSQL {
-		var user User
-		err := sqlDB.QueryRow("SELECT id, username, password, role, created_at FROM users WHERE username = ?",
-			username).Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
-		return &user, nil
-	}
+// ========== Store 委托实现 ==========
 
-	var user *User
-	boltDB.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(usersBucket)
-		c := b.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			var u User
-			json.Unmarshal(v, &u)
-			if u.Username == username {
-				user = &u
-				break
-			}
-		}
-		return nil
-	})
+// GetUserByUsername 根据用户名获取用户
+func (m *Manager) GetUserByUsername(username string) (*User, error) {
+	return m.current().GetUserByUsername(username)
+}
 
-	if user == nil {
-		return nil, fmt.Errorf("user not found")
-	}
-	return user, nil
+// GetUserByID 根据ID获取用户
+func (m *Manager) GetUserByID(id int64) (*User, error) {
+	return m.current().GetUserByID(id)
 }
 
 // GetAllUsers 获取所有用户
-func GetAllUsers() ([]User, error) {
-	if usingSQL {
-		rows, err := sqlDB.Query("SELECT id, username, role, created_at FROM users ORDER BY id")
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-
-		var users []User
-		for rows.Next() {
-			var u User
-			rows.Scan(&u.ID, &u.Username, &u.Role, &u.CreatedAt)
-			users = append(users, u)
-		}
-		return users, nil
-	}
-
-	var users []User
-	boltDB.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(usersBucket)
-		c := b.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			var u User
-			json.Unmarshal(v, &u)
-			users = append(users, u)
-		}
-		return nil
-	})
-	return users, nil
+func (m *Manager) GetAllUsers() ([]User, error) {
+	return m.current().GetAllUsers()
 }
 
 // CreateUser 创建用户
-func CreateUser(username, hashedPassword, role string) error {
-	if usingSQL {
-		_, err := sqlDB.Exec("INSERT INTO users (username, password, role) VALUES (?, ?, ?)",
-			username, hashedPassword, role)
-		return err
-	}
-
-	return boltDB.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(usersBucket)
-
-		// 检查用户名是否已存在
-		c := b.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			var u User
-			json.Unmarshal(v, &u)
-			if u.Username == username {
-				return fmt.Errorf("username already exists")
-			}
-		}
-
-		id := getNextID(tx, usersBucket)
-		user := User{
-			ID:        id,
-			Username:  username,
-			Password:  hashedPassword,
-			Role:      role,
-			CreatedAt: time.Now(),
-		}
-
-		data, _ := json.Marshal(user)
-		key := fmt.Sprintf("%d", id)
-		return b.Put([]byte(key), data)
-	})
+func (m *Manager) CreateUser(username, hashedPassword, role string) error {
+	return m.current().CreateUser(username, hashedPassword, role)
 }
 
 // DeleteUser 删除用户
-func DeleteUser(id int64) error {
-	if usingSQL {
-		sqlDB.Exec("DELETE FROM blood_pressure WHERE user_id = ?", id)
-		_, err := sqlDB.Exec("DELETE FROM users WHERE id = ?", id)
-		return err
-	}
+func (m *Manager) DeleteUser(id int64) error {
+	return m.current().DeleteUser(id)
+}
 
-	// 删除用户的血压记录
-	boltDB.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bpBucket)
-		c := b.Cursor()
-		var toDelete [][]byte
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			var bp BloodPressure
-			json.Unmarshal(v, &bp)
-			if bp.UserID == id {
-				toDelete = append(toDelete, k)
-			}
-		}
-		for _, k := range toDelete {
-			b.Delete(k)
-		}
-		return nil
-	})
+// UpdateUserPassword 更新用户密码
+func (m *Manager) UpdateUserPassword(id int64, hashedPassword string) error {
+	return m.current().UpdateUserPassword(id, hashedPassword)
+}
 
-	return boltDB.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(usersBucket)
-		key := fmt.Sprintf("%d", id)
-		return b.Delete([]byte(key))
-	})
+// GetUserRole 获取用户角色
+func (m *Manager) GetUserRole(id int64) string {
+	return m.current().GetUserRole(id)
 }
 
-// UpdateUserPassword 更新用户密码
-func UpdateUserPassword(id int64, hashedPassword string) error {
-	if usingSQL {
-		_, err := sqlDB.Exec("UPDATE users SET password = ? WHERE id = ?", hashedPassword, id)
-		return err
-	}
+// CountAdmins 统计管理员数量
+func (m *Manager) CountAdmins() int {
+	return m.current().CountAdmins()
+}
 
-	return boltDB.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(usersBucket)
-		key := fmt.Sprintf("%d", id)
-		data := b.Get([]byte(key))
-		if data == nil {
-			return fmt.Errorf("user not found")
-		}
+// UpdateUserRole 更新用户角色
+func (m *Manager) UpdateUserRole(id int64, role string) error {
+	return m.current().UpdateUserRole(id, role)
+}
 
-		var user User
-		json.Unmarshal(data, &user)
-		user.Password = hashedPassword
+// CreateBPRecord 创建健康记录
+func (m *Manager) CreateBPRecord(bp *BloodPressure) (int64, error) {
+	return m.current().CreateBPRecord(bp)
+}
 
-		newData, _ := json.Marshal(user)
-		return b.Put([]byte(key), newData)
-	})
+// GetBPRecords 获取记录时间在 [start, end) 内的健康记录
+func (m *Manager) GetBPRecords(userID int64, start, end time.Time) ([]BloodPressure, error) {
+	return m.current().GetBPRecords(userID, start, end)
 }
 
-// GetUserRole 获取用户角色
-func GetUserRole(id int64) string {
-	if usingSQL {
-		var role string
-		sqlDB.QueryRow("SELECT role FROM users WHERE id = ?", id).Scan(&role)
-		return role
-	}
+// GetUserTimeZone 获取用户时区
+func (m *Manager) GetUserTimeZone(id int64) string {
+	return m.current().GetUserTimeZone(id)
+}
 
-	var role string
-	boltDB.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(usersBucket)
-		key := fmt.Sprintf("%d", id)
-		data := b.Get([]byte(key))
-		if data != nil {
-			var u User
-			json.Unmarshal(data, &u)
-			role = u.Role
-		}
-		return nil
-	})
-	return role
+// UpdateUserTimeZone 更新用户时区
+func (m *Manager) UpdateUserTimeZone(id int64, timeZone string) error {
+	return m.current().UpdateUserTimeZone(id, timeZone)
 }
 
-// CountAdmins 统计管理员数量
-func CountAdmins() int {
-	if usingSQL {
-		var count int
-		sqlDB.QueryRow("SELECT COUNT(*) FROM users WHERE role = 'admin'").Scan(&count)
-		return count
-	}
+// GetUserEmail 获取用户邮箱
+func (m *Manager) GetUserEmail(id int64) string {
+	return m.current().GetUserEmail(id)
+}
 
-	count := 0
-	boltDB.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(usersBucket)
-		c := b.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			var u User
-			json.Unmarshal(v, &u)
-			if u.Role == "admin" {
-				count++
-			}
-		}
-		return nil
-	})
-	return count
+// UpdateUserEmail 更新用户邮箱
+func (m *Manager) UpdateUserEmail(id int64, email string) error {
+	return m.current().UpdateUserEmail(id, email)
 }
 
-// UpdateUserRole 更新用户角色
-func UpdateUserRole(id int64, role string) error {
-	if usingSQL {
-		_, err := sqlDB.Exec("UPDATE users SET role = ? WHERE id = ?", role, id)
-		return err
-	}
+// GetBPRecord 获取用户的单条健康记录
+func (m *Manager) GetBPRecord(id, userID int64) (*BloodPressure, error) {
+	return m.current().GetBPRecord(id, userID)
+}
 
-	return boltDB.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(usersBucket)
-		key := fmt.Sprintf("%d", id)
-		data := b.Get([]byte(key))
-		if data == nil {
-			return fmt.Errorf("user not found")
-		}
+// UpdateBPRecord 修改健康记录
+func (m *Manager) UpdateBPRecord(bp *BloodPressure) error {
+	return m.current().UpdateBPRecord(bp)
+}
 
-		var user User
-		json.Unmarshal(data, &user)
-		user.Role = role
+// DeleteBPRecord 删除血压记录
+func (m *Manager) DeleteBPRecord(id, userID int64) error {
+	return m.current().DeleteBPRecord(id, userID)
+}
 
-		newData, _ := json.Marshal(user)
-		return b.Put([]byte(key), newData)
-	})
+// CreateMeasurement 创建通用测量记录
+func (m *Manager) CreateMeasurement(rec *Measurement) (int64, error) {
+	return m.current().CreateMeasurement(rec)
 }
 
-// ========== 健康记录操作 ==========
+// GetMeasurements 获取记录时间在 [start, end) 内的通用测量记录
+func (m *Manager) GetMeasurements(userID int64, typ string, start, end time.Time) ([]Measurement, error) {
+	return m.current().GetMeasurements(userID, typ, start, end)
+}
 
-// CreateBPRecord 创建健康记录
-func CreateBPRecord(userID int64, systolic, diastolic, heartRate int, height, weight, waistline float64, recordTime time.Time, notes string) (int64, error) {
-	if usingSQL {
-		result, err := sqlDB.Exec(`INSERT INTO blood_pressure (user_id, systolic, diastolic, heart_rate, height, weight, waistline, record_time, notes) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, userID, systolic, diastolic, heartRate, height, weight, waistline, recordTime, notes)
-		if err != nil {
-			return 0, err
-		}
-		return result.LastInsertId()
-	}
+// GetMeasurement 获取用户的单条通用测量记录
+func (m *Manager) GetMeasurement(id, userID int64) (*Measurement, error) {
+	return m.current().GetMeasurement(id, userID)
+}
 
-	var id int64
-	err := boltDB.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bpBucket)
-		id = getNextID(tx, bpBucket)
-
-		bp := BloodPressure{
-			ID:         id,
-			UserID:     userID,
-			Systolic:   systolic,
-			Diastolic:  diastolic,
-			HeartRate:  heartRate,
-			Height:     height,
-			Weight:     weight,
-			Waistline:  waistline,
-			RecordTime: recordTime,
-			Notes:      notes,
-			CreatedAt:  time.Now(),
-		}
+// UpdateMeasurement 修改通用测量记录
+func (m *Manager) UpdateMeasurement(rec *Measurement) error {
+	return m.current().UpdateMeasurement(rec)
+}
 
-		data, _ := json.Marshal(bp)
-		key := fmt.Sprintf("%d", id)
-		return b.Put([]byte(key), data)
-	})
+// DeleteMeasurement 删除通用测量记录
+func (m *Manager) DeleteMeasurement(id, userID int64) error {
+	return m.current().DeleteMeasurement(id, userID)
+}
 
-	return id, err
+// CreateMedication 添加药物
+func (m *Manager) CreateMedication(med *Medication) (int64, error) {
+	return m.current().CreateMedication(med)
 }
 
-// GetBPRecords 获取健康记录
-func GetBPRecords(userID int64, startDate, endDate string) ([]BloodPressure, error) {
-	if usingSQL {
-		query := "SELECT id, systolic, diastolic, heart_rate, height, weight, waistline, record_time, notes FROM blood_pressure WHERE user_id = ?"
-		args := []interface{}{userID}
+// GetMedications 获取用户的全部药物
+func (m *Manager) GetMedications(userID int64) ([]Medication, error) {
+	return m.current().GetMedications(userID)
+}
 
-		if startDate != "" {
-			query += " AND DATE(record_time) >= ?"
-			args = append(args, startDate)
-		}
-		if endDate != "" {
-			query += " AND DATE(record_time) <= ?"
-			args = append(args, endDate)
-		}
-		query += " ORDER BY record_time DESC"
+// GetMedication 获取用户的单个药物
+func (m *Manager) GetMedication(id, userID int64) (*Medication, error) {
+	return m.current().GetMedication(id, userID)
+}
 
-		rows, err := sqlDB.Query(query, args...)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-
-		var records []BloodPressure
-		for rows.Next() {
-			var bp BloodPressure
-			rows.Scan(&bp.ID, &bp.Systolic, &bp.Diastolic, &bp.HeartRate, &bp.Height, &bp.Weight, &bp.Waistline, &bp.RecordTime, &bp.Notes)
-			bp.UserID = userID
-			records = append(records, bp)
-		}
-		return records, nil
-	}
+// UpdateMedication 修改药物
+func (m *Manager) UpdateMedication(med *Medication) error {
+	return m.current().UpdateMedication(med)
+}
 
-	var records []BloodPressure
-	boltDB.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bpBucket)
-		c := b.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			var bp BloodPressure
-			json.Unmarshal(v, &bp)
-			if bp.UserID != userID {
-				continue
-			}
-
-			dateStr := bp.RecordTime.Format("2006-01-02")
-			if startDate != "" && dateStr < startDate {
-				continue
-			}
-			if endDate != "" && dateStr > endDate {
-				continue
-			}
-
-			records = append(records, bp)
-		}
-		return nil
-	})
+// DeleteMedication 删除药物
+func (m *Manager) DeleteMedication(id, userID int64) error {
+	return m.current().DeleteMedication(id, userID)
+}
 
-	// 按时间倒序
-	sort.Slice(records, func(i, j int) bool {
-		return records[i].RecordTime.After(records[j].RecordTime)
-	})
+// CreateIntake 添加服药记录
+func (m *Manager) CreateIntake(in *MedicationIntake) (int64, error) {
+	return m.current().CreateIntake(in)
+}
 
-	return records, nil
+// GetIntakes 获取服药时间在 [start, end) 内的服药记录
+func (m *Manager) GetIntakes(userID int64, start, end time.Time) ([]MedicationIntake, error) {
+	return m.current().GetIntakes(userID, start, end)
 }
 
-// DeleteBPRecord 删除血压记录
-func DeleteBPRecord(id, userID int64) error {
-	if usingSQL {
-		result, err := sqlDB.Exec("DELETE FROM blood_pressure WHERE id = ? AND user_id = ?", id, userID)
-		if err != nil {
-			return err
-		}
-		affected, _ := result.RowsAffected()
-		if affected == 0 {
-			return fmt.Errorf("record not found")
-		}
-		return nil
-	}
+// DeleteIntake 删除服药记录
+func (m *Manager) DeleteIntake(id, userID int64) error {
+	return m.current().DeleteIntake(id, userID)
+}
 
-	return boltDB.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bpBucket)
-		key := fmt.Sprintf("%d", id)
-		data := b.Get([]byte(key))
-		if data == nil {
-			return fmt.Errorf("record not found")
-		}
+// CreateAlert 添加提醒事件
+func (m *Manager) CreateAlert(a *Alert) (int64, error) {
+	return m.current().CreateAlert(a)
+}
 
-		var bp BloodPressure
-		json.Unmarshal(data, &bp)
-		if bp.UserID != userID {
-			return fmt.Errorf("record not found")
-		}
+// GetAlerts 获取触发时间在 [start, end) 内的提醒事件
+func (m *Manager) GetAlerts(userID int64, start, end time.Time) ([]Alert, error) {
+	return m.current().GetAlerts(userID, start, end)
+}
 
-		return b.Delete([]byte(key))
-	})
+// MarkAlertRead 将提醒事件标记为已读
+func (m *Manager) MarkAlertRead(id, userID int64) error {
+	return m.current().MarkAlertRead(id, userID)
 }
 
-// ========== 数据库备份还原 ==========
+// GetSetting 获取全局设置
+func (m *Manager) GetSetting(key string) (string, error) {
+	return m.current().GetSetting(key)
+}
 
-// BackupDB 备份数据库到指定路径
-func BackupDB(destPath string) error {
-	if usingSQL {
-		return fmt.Errorf("MySQL数据库请使用mysqldump进行备份")
-	}
+// SetSetting 保存全局设置
+func (m *Manager) SetSetting(key, value string) error {
+	return m.current().SetSetting(key, value)
+}
 
-	if boltDB == nil {
-		return fmt.Errorf("数据库未初始化")
-	}
+// GetUserSetting 获取用户设置
+func (m *Manager) GetUserSetting(userID int64, key string) (string, error) {
+	return m.current().GetUserSetting(userID, key)
+}
 
-	destFile, err := os.Create(destPath)
-	if err != nil {
-		return fmt.Errorf("无法创建备份文件: %v", err)
-	}
-	defer destFile.Close()
+// SetUserSetting 保存用户设置，value 为空时删除
+func (m *Manager) SetUserSetting(userID int64, key, value string) error {
+	return m.current().SetUserSetting(userID, key, value)
+}
 
-	return boltDB.View(func(tx *bolt.Tx) error {
-		_, err := tx.WriteTo(destFile)
-		return err
-	})
+// ExportUsers 逐个导出用户
+func (m *Manager) ExportUsers(fn func(u User) error) error {
+	return m.current().ExportUsers(fn)
 }
 
-// RestoreDB 从指定路径还原数据库
-func RestoreDB(srcPath string) error {
-	if usingSQL {
-		return fmt.Errorf("MySQL数据库请使用mysql命令进行还原")
-	}
+// ExportBPRecords 逐条导出健康记录
+func (m *Manager) ExportBPRecords(fn func(bp BloodPressure) error) error {
+	return m.current().ExportBPRecords(fn)
+}
 
-	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
-		return fmt.Errorf("备份文件不存在: %s", srcPath)
-	}
+// ExportMeasurements 逐条导出通用测量记录
+func (m *Manager) ExportMeasurements(fn func(rec Measurement) error) error {
+	return m.current().ExportMeasurements(fn)
+}
 
-	dbMux.Lock()
-	defer dbMux.Unlock()
+// ExportSettings 逐项导出全局设置
+func (m *Manager) ExportSettings(fn func(key, value string) error) error {
+	return m.current().ExportSettings(fn)
+}
 
-	if boltDB != nil {
-		boltDB.Close()
-		boltDB = nil
-	}
+// ExportUserSettings 逐项导出用户设置
+func (m *Manager) ExportUserSettings(fn func(userID int64, key, value string) error) error {
+	return m.current().ExportUserSettings(fn)
+}
 
-	dbPath := "data/health_manager.db"
+// ExportMedications 逐个导出药物
+func (m *Manager) ExportMedications(fn func(med Medication) error) error {
+	return m.current().ExportMedications(fn)
+}
 
-	srcFile, err := os.Open(srcPath)
-	if err != nil {
-		connectBolt()
-		return fmt.Errorf("无法打开备份文件: %v", err)
-	}
-	defer srcFile.Close()
+// ExportIntakes 逐条导出服药记录
+func (m *Manager) ExportIntakes(fn func(in MedicationIntake) error) error {
+	return m.current().ExportIntakes(fn)
+}
 
-	destFile, err := os.Create(dbPath)
-	if err != nil {
-		connectBolt()
-		return fmt.Errorf("无法创建数据库文件: %v", err)
-	}
-	defer destFile.Close()
+// ExportAlerts 逐条导出提醒事件
+func (m *Manager) ExportAlerts(fn func(a Alert) error) error {
+	return m.current().ExportAlerts(fn)
+}
 
-	if _, err := io.Copy(destFile, srcFile); err != nil {
-		connectBolt()
-		return fmt.Errorf("复制文件失败: %v", err)
-	}
+// ImportUser 按原ID写入用户
+func (m *Manager) ImportUser(u User) error {
+	return m.current().ImportUser(u)
+}
 
-	destFile.Close()
-	return connectBolt()
+// ImportBPRecord 按原ID写入健康记录
+func (m *Manager) ImportBPRecord(bp BloodPressure) error {
+	return m.current().ImportBPRecord(bp)
 }
 
-// GetSetting 获取全局设置
-func GetSetting(key string) (string, error) {
-	dbMux.Lock()
-	defer dbMux.Unlock()
-
-	if usingSQL {
-		var value string
-		err := sqlDB.QueryRow("SELECT setting_value FROM settings WHERE setting_key = ?", key).Scan(&value)
-		if err == sql.ErrNoRows {
-			return "", nil
-		}
-		return value, err
-	}
+// ImportMeasurement 按原ID写入通用测量记录
+func (m *Manager) ImportMeasurement(rec Measurement) error {
+	return m.current().ImportMeasurement(rec)
+}
 
-	var value string
-	err := boltDB.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(metaBucket)
-		v := b.Get([]byte(key))
-		if v != nil {
-			value = string(v)
-		}
-		return nil
-	})
-	return value, err
+// ImportMedication 按原ID写入药物
+func (m *Manager) ImportMedication(med Medication) error {
+	return m.current().ImportMedication(med)
 }
 
-// SetSetting 保存全局设置
-func SetSetting(key, value string) error {
-	dbMux.Lock()
-	defer dbMux.Unlock()
+// ImportIntake 按原ID写入服药记录
+func (m *Manager) ImportIntake(in MedicationIntake) error {
+	return m.current().ImportIntake(in)
+}
 
-	if usingSQL {
-		_, err := sqlDB.Exec("INSERT INTO settings (setting_key, setting_value) VALUES (?, ?) ON DUPLICATE KEY UPDATE setting_value = ?", key, value, value)
-		return err
-	}
+// ImportAlert 按原ID写入提醒事件
+func (m *Manager) ImportAlert(a Alert) error {
+	return m.current().ImportAlert(a)
+}
 
-	return boltDB.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(metaBucket)
-		return b.Put([]byte(key), []byte(value))
-	})
+// ClearData 清空用户、健康记录、通用测量记录、用药数据、提醒事件、全局设置和用户设置
+func (m *Manager) ClearData() error {
+	return m.current().ClearData()
 }
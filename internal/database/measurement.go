@@ -0,0 +1,151 @@
+package database
+
+import (
+	"sort"
+	"time"
+)
+
+// Measurement 通用测量记录。Values 按测量类型保存一个或多个数值，新增指标无需修改表结构
+type Measurement struct {
+	ID         int64              `json:"id"`
+	UserID     int64              `json:"user_id"`
+	Type       string             `json:"type"`
+	Values     map[string]float64 `json:"values"`
+	Unit       string             `json:"unit"`
+	Context    string             `json:"context,omitempty"` // 测量场景（如空腹、餐后），可选
+	RecordTime time.Time          `json:"record_time"`
+	Notes      string             `json:"notes"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  *time.Time         `json:"updated_at,omitempty"`
+	// Source 为 "bp" 时表示由健康记录映射而来，ID 为健康记录ID，只能通过 /api/bp/:id 修改或删除
+	Source string `json:"source,omitempty"`
+}
+
+// 测量类型
+const (
+	MeasureBloodPressure   = "blood_pressure"
+	MeasureHeartRate       = "heart_rate"
+	MeasureHeight          = "height"
+	MeasureWeight          = "weight"
+	MeasureWaistline       = "waistline"
+	MeasureBloodGlucose    = "blood_glucose"
+	MeasureBodyTemperature = "body_temperature"
+	MeasureSpO2            = "spo2"
+	MeasureRespiratoryRate = "respiratory_rate"
+)
+
+// SourceBP 由健康记录映射而来的测量记录来源
+const SourceBP = "bp"
+
+// MeasurementType 测量类型定义
+type MeasurementType struct {
+	Type     string   `json:"type"`
+	Name     string   `json:"name"`
+	Unit     string   `json:"unit"`
+	Fields   []string `json:"fields"`   // 允许的数值字段
+	Required []string `json:"required"` // 必填的数值字段
+	// BPField 为 true 时该类型写入健康记录表，保证 /api/bp 仍能看到
+	BPField bool `json:"bp_field"`
+}
+
+// MeasurementTypes 支持的测量类型，单值指标的数值字段统一为 "value"
+var MeasurementTypes = []MeasurementType{
+	{Type: MeasureBloodPressure, Name: "血压", Unit: "mmHg", Fields: []string{"systolic", "diastolic", "heart_rate"}, Required: []string{"systolic", "diastolic"}, BPField: true},
+	{Type: MeasureHeartRate, Name: "心率", Unit: "次/分", Fields: []string{"value"}, Required: []string{"value"}, BPField: true},
+	{Type: MeasureHeight, Name: "身高", Unit: "cm", Fields: []string{"value"}, Required: []string{"value"}, BPField: true},
+	{Type: MeasureWeight, Name: "体重", Unit: "kg", Fields: []string{"value"}, Required: []string{"value"}, BPField: true},
+	{Type: MeasureWaistline, Name: "腰围", Unit: "cm", Fields: []string{"value"}, Required: []string{"value"}, BPField: true},
+	{Type: MeasureBloodGlucose, Name: "血糖", Unit: "mmol/L", Fields: []string{"value"}, Required: []string{"value"}},
+	{Type: MeasureBodyTemperature, Name: "体温", Unit: "°C", Fields: []string{"value"}, Required: []string{"value"}},
+	{Type: MeasureSpO2, Name: "血氧饱和度", Unit: "%", Fields: []string{"value"}, Required: []string{"value"}},
+	{Type: MeasureRespiratoryRate, Name: "呼吸频率", Unit: "次/分", Fields: []string{"value"}, Required: []string{"value"}},
+}
+
+// LookupMeasurementType 按类型名查找测量类型
+func LookupMeasurementType(name string) (MeasurementType, bool) {
+	for _, t := range MeasurementTypes {
+		if t.Type == name {
+			return t, true
+		}
+	}
+	return MeasurementType{}, false
+}
+
+// MeasurementsFromBP 将一条健康记录拆分为血压、心率、身高、体重、腰围测量记录，值为0的指标不输出。
+// 心率只作为单独的心率记录输出，不重复放在血压记录的数值中
+func MeasurementsFromBP(bp BloodPressure) []Measurement {
+	mapped := func(typ string, values map[string]float64) Measurement {
+		t, _ := LookupMeasurementType(typ)
+		return Measurement{
+			ID:         bp.ID,
+			UserID:     bp.UserID,
+			Type:       typ,
+			Values:     values,
+			Unit:       t.Unit,
+			RecordTime: bp.RecordTime,
+			Notes:      bp.Notes,
+			CreatedAt:  bp.CreatedAt,
+			UpdatedAt:  bp.UpdatedAt,
+			Source:     SourceBP,
+		}
+	}
+
+	var list []Measurement
+	if bp.Systolic > 0 || bp.Diastolic > 0 {
+		list = append(list, mapped(MeasureBloodPressure, map[string]float64{"systolic": float64(bp.Systolic), "diastolic": float64(bp.Diastolic)}))
+	}
+	if bp.HeartRate > 0 {
+		list = append(list, mapped(MeasureHeartRate, map[string]float64{"value": float64(bp.HeartRate)}))
+	}
+	if bp.Height > 0 {
+		list = append(list, mapped(MeasureHeight, map[string]float64{"value": bp.Height}))
+	}
+	if bp.Weight > 0 {
+		list = append(list, mapped(MeasureWeight, map[string]float64{"value": bp.Weight}))
+	}
+	if bp.Waistline > 0 {
+		list = append(list, mapped(MeasureWaistline, map[string]float64{"value": bp.Waistline}))
+	}
+	return list
+}
+
+// MappedFromBP 判断该类型的测量记录是否可能由健康记录映射而来
+func MappedFromBP(typ string) bool {
+	switch typ {
+	case MeasureBloodPressure, MeasureHeartRate, MeasureHeight, MeasureWeight, MeasureWaistline:
+		return true
+	}
+	return false
+}
+
+// BPFromMeasurement 将写入健康记录表的测量类型（血压、心率、身高、体重、腰围）转换为健康记录，其他类型返回 false
+func BPFromMeasurement(m Measurement) (BloodPressure, bool) {
+	bp := BloodPressure{UserID: m.UserID, RecordTime: m.RecordTime, Notes: m.Notes}
+	switch m.Type {
+	case MeasureBloodPressure:
+		bp.Systolic = int(m.Values["systolic"])
+		bp.Diastolic = int(m.Values["diastolic"])
+		bp.HeartRate = int(m.Values["heart_rate"])
+	case MeasureHeartRate:
+		bp.HeartRate = int(m.Values["value"])
+	case MeasureHeight:
+		bp.Height = m.Values["value"]
+	case MeasureWeight:
+		bp.Weight = m.Values["value"]
+	case MeasureWaistline:
+		bp.Waistline = m.Values["value"]
+	default:
+		return bp, false
+	}
+	return bp, true
+}
+
+// SortMeasurements 按测量时间倒序排列，时间相同时按ID倒序
+func SortMeasurements(list []Measurement) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if !list[i].RecordTime.Equal(list[j].RecordTime) {
+			return list[i].RecordTime.After(list[j].RecordTime)
+		}
+		return list[i].ID > list[j].ID
+	})
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMeasurementsFromBP(t *testing.T) {
+	bp := BloodPressure{ID: 3, UserID: 1, Systolic: 135, Diastolic: 85, HeartRate: 72, Weight: 65.5, Waistline: 80, RecordTime: time.Now()}
+
+	list := MeasurementsFromBP(bp)
+	got := map[string]map[string]float64{}
+	for _, m := range list {
+		if m.ID != bp.ID || m.Source != SourceBP {
+			t.Errorf("%s: id = %d source = %q", m.Type, m.ID, m.Source)
+		}
+		if _, dup := got[m.Type]; dup {
+			t.Errorf("type %s emitted twice", m.Type)
+		}
+		got[m.Type] = m.Values
+	}
+
+	want := map[string]map[string]float64{
+		MeasureBloodPressure: {"systolic": 135, "diastolic": 85},
+		MeasureHeartRate:     {"value": 72},
+		MeasureWeight:        {"value": 65.5},
+		MeasureWaistline:     {"value": 80},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("types = %v, want %v", got, want)
+	}
+	for typ, values := range want {
+		if len(got[typ]) != len(values) {
+			t.Errorf("%s values = %v, want %v", typ, got[typ], values)
+			continue
+		}
+		for k, v := range values {
+			if got[typ][k] != v {
+				t.Errorf("%s values = %v, want %v", typ, got[typ], values)
+			}
+		}
+	}
+}
+
+func TestBPFromMeasurement(t *testing.T) {
+	tests := []struct {
+		typ    string
+		values map[string]float64
+		want   BloodPressure
+		ok     bool
+	}{
+		{MeasureBloodPressure, map[string]float64{"systolic": 120, "diastolic": 80, "heart_rate": 70}, BloodPressure{Systolic: 120, Diastolic: 80, HeartRate: 70}, true},
+		{MeasureHeartRate, map[string]float64{"value": 66}, BloodPressure{HeartRate: 66}, true},
+		{MeasureHeight, map[string]float64{"value": 170}, BloodPressure{Height: 170}, true},
+		{MeasureWeight, map[string]float64{"value": 60}, BloodPressure{Weight: 60}, true},
+		{MeasureWaistline, map[string]float64{"value": 78}, BloodPressure{Waistline: 78}, true},
+		{MeasureBodyTemperature, map[string]float64{"value": 36.5}, BloodPressure{}, false},
+		{MeasureBloodGlucose, map[string]float64{"value": 5.4}, BloodPressure{}, false},
+	}
+	for _, tt := range tests {
+		bp, ok := BPFromMeasurement(Measurement{UserID: 1, Type: tt.typ, Values: tt.values})
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.typ, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if typ, _ := LookupMeasurementType(tt.typ); !typ.BPField {
+			t.Errorf("%s is written to the BP table but BPField is false", tt.typ)
+		}
+		if bp.Systolic != tt.want.Systolic || bp.Diastolic != tt.want.Diastolic || bp.HeartRate != tt.want.HeartRate ||
+			bp.Height != tt.want.Height || bp.Weight != tt.want.Weight || bp.Waistline != tt.want.Waistline || bp.UserID != 1 {
+			t.Errorf("%s: bp = %+v, want %+v", tt.typ, bp, tt.want)
+		}
+	}
+}
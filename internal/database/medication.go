@@ -0,0 +1,30 @@
+package database
+
+import "time"
+
+// Medication 用户的常用药物及服药计划
+type Medication struct {
+	ID       int64    `json:"id"`
+	UserID   int64    `json:"user_id"`
+	Name     string   `json:"name"`
+	Dose     string   `json:"dose"`     // 每次剂量，如 "5mg"、"1片"
+	Schedule string   `json:"schedule"` // 服药频次说明，如 "每日一次"
+	Times    []string `json:"times"`    // 计划服药时间（HH:MM，用户时区），可为空
+	Notes    string   `json:"notes"`
+	// StoppedAt 停用时间，为空表示正在服用。停用而非删除可保留历史用药
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// MedicationIntake 服药记录。药名与剂量在记录时复制保存，药物修改或删除后历史记录不变
+type MedicationIntake struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	MedicationID int64     `json:"medication_id"` // 0 表示未关联药物列表的临时用药
+	Name         string    `json:"name"`
+	Dose         string    `json:"dose"`
+	TakenAt      time.Time `json:"taken_at"`
+	Notes        string    `json:"notes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
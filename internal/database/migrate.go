@@ -0,0 +1,489 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// schemaVersionKey 数据库结构版本号在 settings 表 / meta bucket 中的键名
+const schemaVersionKey = "schema_version"
+
+// migration 一次版本化的结构迁移。bolt 与 sql 分别为两类后端的实现，为 nil 表示该后端无需变更。
+// 新增迁移只能追加到 migrations 末尾，版本号依次递增，已发布的迁移不得修改。
+type migration struct {
+	version int
+	name    string
+	bolt    func(tx *bolt.Tx) error
+	sql     func(tx *sql.Tx, d dialect) error
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "创建初始表结构",
+		bolt:    boltCreateBuckets,
+		sql:     sqlCreateTables,
+	},
+	{
+		version: 2,
+		name:    "blood_pressure 增加身高、体重、腰围字段",
+		sql:     sqlAddBodyColumns,
+	},
+	{
+		version: 3,
+		name:    "bolt 建立用户名、角色索引，健康记录按用户和时间分组",
+		bolt:    boltIndexData,
+	},
+	{
+		version: 4,
+		name:    "blood_pressure 增加修改时间字段",
+		sql:     sqlAddUpdatedAt,
+	},
+	{
+		version: 5,
+		name:    "users 增加时区字段",
+		sql:     sqlAddUserTimeZone,
+	},
+	{
+		version: 6,
+		name:    "创建通用测量记录表",
+		bolt:    boltCreateMeasurementBuckets,
+		sql:     sqlCreateMeasurements,
+	},
+	{
+		version: 7,
+		name:    "创建用户设置表",
+		bolt:    boltCreateUserSettingsBucket,
+		sql:     sqlCreateUserSettings,
+	},
+	{
+		version: 8,
+		name:    "创建药物与服药记录表",
+		bolt:    boltCreateMedicationBuckets,
+		sql:     sqlCreateMedications,
+	},
+	{
+		version: 9,
+		name:    "创建提醒事件表",
+		bolt:    boltCreateAlertBuckets,
+		sql:     sqlCreateAlerts,
+	},
+	{
+		version: 10,
+		name:    "users 增加邮箱字段",
+		sql:     sqlAddUserEmail,
+	},
+	{
+		version: 11,
+		name:    "SQLite 时间字段统一转为UTC",
+		sql:     sqlNormalizeSQLiteTimes,
+	},
+}
+
+// latestSchemaVersion 返回当前程序支持的最高结构版本
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// runMigrations 从当前版本依次执行后续迁移；数据库版本高于程序时拒绝启动
+func runMigrations(current int, apply func(m migration) error) error {
+	latest := latestSchemaVersion()
+	if current > latest {
+		return fmt.Errorf("数据库结构版本(%d)高于当前程序支持的版本(%d)，请升级程序后再启动", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		log.Printf("正在迁移数据库结构到版本 %d：%s", m.version, m.name)
+		if err := apply(m); err != nil {
+			return fmt.Errorf("迁移到版本 %d 失败: %v", m.version, err)
+		}
+	}
+	return nil
+}
+
+// ========== bolt ==========
+
+// migrateBolt 执行bolt数据库的结构迁移，每个迁移与版本号写入在同一事务中完成
+func migrateBolt(db *bolt.DB) error {
+	var current int
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		if v := b.Get([]byte(schemaVersionKey)); v != nil {
+			current, _ = strconv.Atoi(string(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return runMigrations(current, func(m migration) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			if m.bolt != nil {
+				if err := m.bolt(tx); err != nil {
+					return err
+				}
+			}
+			return tx.Bucket(metaBucket).Put([]byte(schemaVersionKey), []byte(strconv.Itoa(m.version)))
+		})
+	})
+}
+
+func boltCreateBuckets(tx *bolt.Tx) error {
+	if _, err := tx.CreateBucketIfNotExists(usersBucket); err != nil {
+		return err
+	}
+	if _, err := tx.CreateBucketIfNotExists(bpBucket); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ========== sql ==========
+
+// settingsTable 各SQL方言通用的设置表，同时用于保存结构版本号
+const settingsTable = `CREATE TABLE IF NOT EXISTS settings (
+	description VARCHAR(100),
+	setting_key VARCHAR(50) PRIMARY KEY,
+	setting_value TEXT
+)`
+
+// migrateSQL 执行SQL数据库的结构迁移
+func migrateSQL(db *sql.DB, d dialect) error {
+	if _, err := db.Exec(settingsTable); err != nil {
+		return err
+	}
+
+	var current int
+	var value string
+	err := db.QueryRow(d.rebind("SELECT setting_value FROM settings WHERE setting_key = ?"), schemaVersionKey).Scan(&value)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	current, _ = strconv.Atoi(value)
+
+	return runMigrations(current, func(m migration) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if m.sql != nil {
+			if err := m.sql(tx, d); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(d.rebind(d.upsertSetting()), schemaVersionKey, strconv.Itoa(m.version)); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+func sqlCreateTables(tx *sql.Tx, d dialect) error {
+	var schema []string
+	switch d {
+	case dialectMySQL:
+		schema = mysqlSchema
+	case dialectSQLite:
+		schema = sqliteSchema
+	case dialectPostgres:
+		schema = postgresSchema
+	}
+	for _, stmt := range schema {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlAddBodyColumns 为早期仅包含血压字段的 MySQL 旧表补充身体指标字段，
+// SQLite/PostgreSQL 的初始表结构已包含这些字段
+func sqlAddBodyColumns(tx *sql.Tx, d dialect) error {
+	if d != dialectMySQL {
+		return nil
+	}
+
+	columnsToEnsure := []struct {
+		name string
+		spec string
+	}{
+		{"height", "DECIMAL(5,2) DEFAULT 0"},
+		{"weight", "DECIMAL(5,2) DEFAULT 0"},
+		{"waistline", "DECIMAL(5,2) DEFAULT 0"},
+	}
+
+	for _, col := range columnsToEnsure {
+		// 检查列是否存在
+		var count int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM information_schema.columns
+			WHERE table_schema = DATABASE() AND table_name = 'blood_pressure' AND column_name = ?`, col.name).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		log.Printf("正在迁移数据库：为 blood_pressure 表添加 %s 字段", col.name)
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE blood_pressure ADD COLUMN %s %s", col.name, col.spec)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlAddUpdatedAt 增加可空的 updated_at 字段，未修改过的记录为空（bolt 记录为JSON，无需迁移）
+func sqlAddUpdatedAt(tx *sql.Tx, d dialect) error {
+	colType := "DATETIME"
+	if d == dialectPostgres {
+		colType = "TIMESTAMP"
+	}
+	return addColumn(tx, d, "blood_pressure", "updated_at", colType+" NULL")
+}
+
+// sqlAddUserTimeZone 增加用户时区字段，空字符串表示使用服务器默认时区
+func sqlAddUserTimeZone(tx *sql.Tx, d dialect) error {
+	return addColumn(tx, d, "users", "time_zone", "VARCHAR(64) DEFAULT ''")
+}
+
+// sqlCreateMeasurements 创建通用测量记录表，数值以JSON保存，新增指标无需再修改表结构
+func sqlCreateMeasurements(tx *sql.Tx, d dialect) error {
+	idType, timeType, now := "INTEGER PRIMARY KEY AUTOINCREMENT", "DATETIME", "CURRENT_TIMESTAMP"
+	switch d {
+	case dialectMySQL:
+		idType = "BIGINT PRIMARY KEY AUTO_INCREMENT"
+	case dialectPostgres:
+		idType, timeType, now = "BIGSERIAL PRIMARY KEY", "TIMESTAMP", "LOCALTIMESTAMP"
+	}
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS measurements (
+		id ` + idType + `,
+		user_id BIGINT NOT NULL,
+		measure_type VARCHAR(32) NOT NULL,
+		measure_values TEXT NOT NULL,
+		unit VARCHAR(16),
+		context VARCHAR(32),
+		record_time ` + timeType + ` NOT NULL,
+		notes TEXT,
+		created_at ` + timeType + ` DEFAULT ` + now + `,
+		updated_at ` + timeType + ` NULL
+	)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if err := createIndex(tx, d, "idx_measurements_user_time", "measurements", "user_id, record_time"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// sqlCreateUserSettings 创建用户设置表，每个用户的每项设置一行
+func sqlCreateUserSettings(tx *sql.Tx, d dialect) error {
+	_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS user_settings (
+		user_id BIGINT NOT NULL,
+		setting_key VARCHAR(50) NOT NULL,
+		setting_value TEXT,
+		PRIMARY KEY (user_id, setting_key)
+	)`)
+	return err
+}
+
+// sqlCreateMedications 创建药物表与服药记录表，计划服药时间以JSON保存
+func sqlCreateMedications(tx *sql.Tx, d dialect) error {
+	idType, timeType, now := "INTEGER PRIMARY KEY AUTOINCREMENT", "DATETIME", "CURRENT_TIMESTAMP"
+	switch d {
+	case dialectMySQL:
+		idType = "BIGINT PRIMARY KEY AUTO_INCREMENT"
+	case dialectPostgres:
+		idType, timeType, now = "BIGSERIAL PRIMARY KEY", "TIMESTAMP", "LOCALTIMESTAMP"
+	}
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS medications (
+		id ` + idType + `,
+		user_id BIGINT NOT NULL,
+		name VARCHAR(100) NOT NULL,
+		dose VARCHAR(50),
+		schedule VARCHAR(100),
+		schedule_times TEXT,
+		notes TEXT,
+		stopped_at ` + timeType + ` NULL,
+		created_at ` + timeType + ` DEFAULT ` + now + `,
+		updated_at ` + timeType + ` NULL
+	)`,
+		`CREATE TABLE IF NOT EXISTS medication_intakes (
+		id ` + idType + `,
+		user_id BIGINT NOT NULL,
+		medication_id BIGINT NOT NULL DEFAULT 0,
+		name VARCHAR(100) NOT NULL,
+		dose VARCHAR(50),
+		taken_at ` + timeType + ` NOT NULL,
+		notes TEXT,
+		created_at ` + timeType + ` DEFAULT ` + now + `
+	)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if err := createIndex(tx, d, "idx_medications_user", "medications", "user_id"); err != nil {
+		return err
+	}
+	if err := createIndex(tx, d, "idx_medication_intakes_user_time", "medication_intakes", "user_id, taken_at"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// sqlCreateAlerts 创建提醒事件表
+func sqlCreateAlerts(tx *sql.Tx, d dialect) error {
+	idType, timeType, now := "INTEGER PRIMARY KEY AUTOINCREMENT", "DATETIME", "CURRENT_TIMESTAMP"
+	switch d {
+	case dialectMySQL:
+		idType = "BIGINT PRIMARY KEY AUTO_INCREMENT"
+	case dialectPostgres:
+		idType, timeType, now = "BIGSERIAL PRIMARY KEY", "TIMESTAMP", "LOCALTIMESTAMP"
+	}
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS alerts (
+		id ` + idType + `,
+		user_id BIGINT NOT NULL,
+		record_id BIGINT NOT NULL DEFAULT 0,
+		metric VARCHAR(30) NOT NULL,
+		operator VARCHAR(5) NOT NULL,
+		threshold DOUBLE PRECISION NOT NULL,
+		value DOUBLE PRECISION NOT NULL,
+		message VARCHAR(255),
+		triggered_at ` + timeType + ` NOT NULL,
+		read_at ` + timeType + ` NULL,
+		created_at ` + timeType + ` DEFAULT ` + now + `
+	)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if err := createIndex(tx, d, "idx_alerts_user_time", "alerts", "user_id, triggered_at"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// sqlAddUserEmail 增加用户邮箱字段，空字符串表示不发送邮件
+func sqlAddUserEmail(tx *sql.Tx, d dialect) error {
+	return addColumn(tx, d, "users", "email", "VARCHAR(255) DEFAULT ''")
+}
+
+// sqliteTimeColumns SQLite 中参与范围查询和排序的时间字段
+var sqliteTimeColumns = [][2]string{
+	{"blood_pressure", "record_time"},
+	{"measurements", "record_time"},
+	{"medication_intakes", "taken_at"},
+	{"alerts", "triggered_at"},
+}
+
+// sqlNormalizeSQLiteTimes 将 SQLite 中按原时区偏移保存的时间改写为UTC，
+// 使文本顺序与时间顺序一致，范围查询可直接比较字段（MySQL/PostgreSQL 使用时间类型，无需迁移）
+func sqlNormalizeSQLiteTimes(tx *sql.Tx, d dialect) error {
+	if d != dialectSQLite {
+		return nil
+	}
+
+	type row struct {
+		id int64
+		t  time.Time
+	}
+	for _, col := range sqliteTimeColumns {
+		rows, err := tx.Query(fmt.Sprintf("SELECT id, %s FROM %s", col[1], col[0]))
+		if err != nil {
+			return err
+		}
+		var list []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.t); err != nil {
+				rows.Close()
+				return fmt.Errorf("%s.%s: %v", col[0], col[1], err)
+			}
+			list = append(list, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, r := range list {
+			if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", col[0], col[1]), d.timeArg(r.t), r.id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MySQL 的 DDL 会隐式提交，迁移中途失败时已执行的 ALTER/CREATE INDEX 不会回滚，
+// 结构版本却未记录，因此加字段、建索引都先检查是否已存在，重新执行迁移时跳过
+
+// columnExists 判断表中是否已有该字段
+func columnExists(tx *sql.Tx, d dialect, table, column string) (bool, error) {
+	var query string
+	switch d {
+	case dialectMySQL:
+		query = "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?"
+	case dialectPostgres:
+		query = "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?"
+	default:
+		query = "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?"
+	}
+	var count int
+	if err := tx.QueryRow(d.rebind(query), table, column).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// addColumn 字段不存在时为表增加字段
+func addColumn(tx *sql.Tx, d dialect, table, column, spec string) error {
+	exists, err := columnExists(tx, d, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, spec))
+	return err
+}
+
+// createIndex 索引不存在时创建索引，MySQL 不支持 CREATE INDEX IF NOT EXISTS，需先查询
+func createIndex(tx *sql.Tx, d dialect, name, table, columns string) error {
+	if d != dialectMySQL {
+		_, err := tx.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", name, table, columns))
+		return err
+	}
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?`, table, name).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("CREATE INDEX %s ON %s (%s)", name, table, columns))
+	return err
+}
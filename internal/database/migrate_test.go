@@ -0,0 +1,275 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// migrateOld 模拟只支持到 version 的旧版程序执行迁移，version 为 0 时保持空库
+func migrateOld(version int, migrate func() error) error {
+	if version == 0 {
+		return nil
+	}
+	all := migrations
+	migrations = all[:version]
+	defer func() { migrations = all }()
+	return migrate()
+}
+
+// sqlMigrationColumns 各版本SQL迁移后应存在的字段
+var sqlMigrationColumns = map[int][][2]string{
+	1:  {{"users", "username"}, {"blood_pressure", "record_time"}, {"settings", "setting_value"}},
+	2:  {{"blood_pressure", "height"}, {"blood_pressure", "weight"}, {"blood_pressure", "waistline"}},
+	4:  {{"blood_pressure", "updated_at"}},
+	5:  {{"users", "time_zone"}},
+	6:  {{"measurements", "measure_values"}, {"measurements", "record_time"}},
+	7:  {{"user_settings", "setting_value"}},
+	8:  {{"medications", "schedule_times"}, {"medication_intakes", "taken_at"}},
+	9:  {{"alerts", "triggered_at"}, {"alerts", "read_at"}},
+	10: {{"users", "email"}},
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	for _, m := range migrations {
+		t.Run(strconv.Itoa(m.version), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.sqlite")
+			db, err := sql.Open("sqlite", "file:"+path+"?_time_format=sqlite")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			// 旧版程序建库并写入数据
+			if err := migrateOld(m.version-1, func() error { return migrateSQL(db, dialectSQLite) }); err != nil {
+				t.Fatal(err)
+			}
+			if m.version > 1 {
+				if _, err := db.Exec("INSERT INTO users (username, password, role) VALUES ('alice', 'hash', 'user')"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := migrateSQL(db, dialectSQLite); err != nil {
+				t.Fatal(err)
+			}
+
+			var version string
+			if err := db.QueryRow("SELECT setting_value FROM settings WHERE setting_key = ?", schemaVersionKey).Scan(&version); err != nil {
+				t.Fatal(err)
+			}
+			if version != strconv.Itoa(latestSchemaVersion()) {
+				t.Errorf("schema_version = %s, want %d", version, latestSchemaVersion())
+			}
+
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+			for _, col := range sqlMigrationColumns[m.version] {
+				if ok, err := columnExists(tx, dialectSQLite, col[0], col[1]); err != nil || !ok {
+					t.Errorf("column %s.%s exists = %v, %v", col[0], col[1], ok, err)
+				}
+			}
+
+			// 迁移前写入的用户在新结构下仍可读取
+			if m.version > 1 {
+				s := &sqlStore{db: db, dialect: dialectSQLite}
+				u, err := s.GetUserByUsername("alice")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if u.TimeZone != "" || u.Email != "" {
+					t.Errorf("migrated user = %+v", u)
+				}
+			}
+		})
+	}
+}
+
+// boltMigrationBuckets 各版本bolt迁移后应存在的bucket
+var boltMigrationBuckets = map[int][][]byte{
+	1: {usersBucket, bpBucket},
+	3: {usersByNameBucket, usersByRoleBucket, bpIDBucket},
+	6: {measurementsBucket, measurementIDBucket},
+	7: {userSettingsBucket},
+	8: {medicationsBucket, intakesBucket, intakeIDBucket},
+	9: {alertsBucket, alertIDBucket},
+}
+
+// openRawBolt 打开bolt文件但不执行迁移
+func openRawBolt(t *testing.T, path string) *bolt.DB {
+	t.Helper()
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMigrateBolt(t *testing.T) {
+	for _, m := range migrations {
+		t.Run(strconv.Itoa(m.version), func(t *testing.T) {
+			db := openRawBolt(t, filepath.Join(t.TempDir(), "test.db"))
+			defer db.Close()
+
+			if err := migrateOld(m.version-1, func() error { return migrateBolt(db) }); err != nil {
+				t.Fatal(err)
+			}
+			if err := migrateBolt(db); err != nil {
+				t.Fatal(err)
+			}
+
+			err := db.View(func(tx *bolt.Tx) error {
+				if v := string(tx.Bucket(metaBucket).Get([]byte(schemaVersionKey))); v != strconv.Itoa(latestSchemaVersion()) {
+					t.Errorf("schema_version = %s, want %d", v, latestSchemaVersion())
+				}
+				for _, name := range boltMigrationBuckets[m.version] {
+					if tx.Bucket(name) == nil {
+						t.Errorf("bucket %s missing", name)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestMigrateBoltIndex 版本2的bolt数据库中用户没有索引、健康记录以十进制ID为键平铺，迁移到版本3后按用户和时间重建
+func TestMigrateBoltIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openRawBolt(t, path)
+	if err := migrateOld(2, func() error { return migrateBolt(db) }); err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2024, 3, 5, 7, 30, 0, 0, time.UTC)
+	users := []User{{ID: 1, Username: "admin", Role: "admin"}, {ID: 2, Username: "alice", Role: "user"}}
+	records := []BloodPressure{
+		{ID: 1, UserID: 2, Systolic: 120, Diastolic: 80, RecordTime: at},
+		{ID: 2, UserID: 1, Systolic: 130, Diastolic: 85, RecordTime: at},
+		{ID: 3, UserID: 2, Systolic: 140, Diastolic: 90, RecordTime: at.Add(-24 * time.Hour)},
+		{ID: 10, UserID: 2, Systolic: 125, Diastolic: 82, RecordTime: at.Add(time.Hour)},
+	}
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, u := range users {
+			data, _ := json.Marshal(u)
+			if err := tx.Bucket(usersBucket).Put(userKey(u.ID), data); err != nil {
+				return err
+			}
+		}
+		for _, bp := range records {
+			data, _ := json.Marshal(bp)
+			if err := tx.Bucket(bpBucket).Put([]byte(strconv.FormatInt(bp.ID, 10)), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s, err := openBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, want := range users {
+		u, err := s.GetUserByUsername(want.Username)
+		if err != nil || u.ID != want.ID || u.Role != want.Role {
+			t.Errorf("GetUserByUsername(%s) = %+v, %v", want.Username, u, err)
+		}
+	}
+
+	list, err := s.GetBPRecords(2, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, bp := range list {
+		ids = append(ids, bp.ID)
+	}
+	if len(ids) != 3 || ids[0] != 10 || ids[1] != 1 || ids[2] != 3 {
+		t.Errorf("alice's records = %v, want [10 1 3] (newest first)", ids)
+	}
+	if bp, err := s.GetBPRecord(2, 1); err != nil || bp.Systolic != 130 {
+		t.Errorf("GetBPRecord(2, 1) = %+v, %v", bp, err)
+	}
+	if _, err := s.GetBPRecord(2, 2); err == nil {
+		t.Error("GetBPRecord returned another user's record")
+	}
+}
+
+// TestMigrateSQLiteTimes 版本10及之前的 SQLite 按写入时的时区偏移保存时间，迁移后统一为UTC
+func TestMigrateSQLiteTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+	db, err := sql.Open("sqlite", "file:"+path+"?_time_format=sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateOld(10, func() error { return migrateSQL(db, dialectSQLite) }); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一时刻 07:30Z 以不同偏移保存，另有一条更早的 03:00Z
+	stmts := []string{
+		"INSERT INTO users (id, username, password, role) VALUES (1, 'alice', 'hash', 'user')",
+		"INSERT INTO blood_pressure (id, user_id, systolic, diastolic, heart_rate, record_time) VALUES (1, 1, 120, 80, 70, '2024-03-05 15:30:00+08:00')",
+		"INSERT INTO blood_pressure (id, user_id, systolic, diastolic, heart_rate, record_time) VALUES (2, 1, 130, 85, 72, '2024-03-05 03:00:00+00:00')",
+		"INSERT INTO blood_pressure (id, user_id, systolic, diastolic, heart_rate, record_time) VALUES (3, 1, 125, 82, 68, '2024-03-05 02:30:00-05:00')",
+		"INSERT INTO measurements (id, user_id, measure_type, measure_values, record_time) VALUES (1, 1, 'blood_glucose', '{}', '2024-03-05 15:30:00+08:00')",
+		"INSERT INTO medication_intakes (id, user_id, name, taken_at) VALUES (1, 1, 'amlodipine', '2024-03-05 15:30:00.5+08:00')",
+		"INSERT INTO alerts (id, user_id, metric, operator, threshold, value, triggered_at) VALUES (1, 1, 'systolic', '>=', 120, 120, '2024-03-05 15:30:00+08:00')",
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	s, err := openSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, col := range sqliteTimeColumns {
+		var raw string
+		if err := s.db.QueryRow(fmt.Sprintf("SELECT CAST(%s AS TEXT) FROM %s WHERE id = 1", col[1], col[0])).Scan(&raw); err != nil {
+			t.Fatal(err)
+		}
+		want := "2024-03-05 07:30:00+00:00"
+		if col[0] == "medication_intakes" {
+			want = "2024-03-05 07:30:00.5+00:00"
+		}
+		if raw != want {
+			t.Errorf("%s.%s = %q, want %q", col[0], col[1], raw, want)
+		}
+	}
+
+	at := time.Date(2024, 3, 5, 7, 30, 0, 0, time.UTC)
+	list, err := s.GetBPRecords(1, at, at.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !list[0].RecordTime.Equal(at) || !list[1].RecordTime.Equal(at) {
+		t.Errorf("records at %s = %+v, want ids 1 and 3", at, list)
+	}
+	if list, _ := s.GetBPRecords(1, time.Time{}, time.Time{}); len(list) != 3 || list[2].ID != 2 {
+		t.Errorf("records = %+v, want id 2 last", list)
+	}
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"health-manager/internal/config"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// mysqlDSN 根据配置生成MySQL连接串
+func mysqlDSN(cfg *config.DBConfig) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName)
+}
+
+// connectMySQL 连接MySQL
+func connectMySQL(cfg *config.DBConfig) (*sqlStore, error) {
+	db, err := sql.Open("mysql", mysqlDSN(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrateSQL(db, dialectMySQL); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlStore{db: db, dialect: dialectMySQL}, nil
+}
+
+// mysqlSchema MySQL初始表结构（迁移版本1）
+var mysqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+		id BIGINT PRIMARY KEY AUTO_INCREMENT,
+		username VARCHAR(50) UNIQUE NOT NULL,
+		password VARCHAR(255) NOT NULL,
+		role VARCHAR(20) DEFAULT 'user',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS blood_pressure (
+		id BIGINT PRIMARY KEY AUTO_INCREMENT,
+		user_id BIGINT NOT NULL,
+		systolic INT,
+		diastolic INT,
+		heart_rate INT,
+		height DECIMAL(5,2),
+		weight DECIMAL(5,2),
+		waistline DECIMAL(5,2),
+		record_time DATETIME NOT NULL,
+		notes TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"database/sql"
+	"net"
+	"net/url"
+
+	"health-manager/internal/config"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// postgresDSN 根据配置生成PostgreSQL连接串
+func postgresDSN(cfg *config.DBConfig) string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.User, cfg.Password),
+		Host:   net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:   "/" + cfg.DBName,
+	}
+	return u.String()
+}
+
+// connectPostgres 连接PostgreSQL
+func connectPostgres(cfg *config.DBConfig) (*sqlStore, error) {
+	db, err := sql.Open("pgx", postgresDSN(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrateSQL(db, dialectPostgres); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlStore{db: db, dialect: dialectPostgres}, nil
+}
+
+// postgresSchema PostgreSQL初始表结构（迁移版本1）
+var postgresSchema = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+		id BIGSERIAL PRIMARY KEY,
+		username VARCHAR(50) UNIQUE NOT NULL,
+		password VARCHAR(255) NOT NULL,
+		role VARCHAR(20) DEFAULT 'user',
+		created_at TIMESTAMP DEFAULT LOCALTIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS blood_pressure (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL,
+		systolic INT,
+		diastolic INT,
+		heart_rate INT,
+		height NUMERIC(5,2) DEFAULT 0,
+		weight NUMERIC(5,2) DEFAULT 0,
+		waistline NUMERIC(5,2) DEFAULT 0,
+		record_time TIMESTAMP NOT NULL,
+		notes TEXT,
+		created_at TIMESTAMP DEFAULT LOCALTIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_bp_user_time ON blood_pressure (user_id, record_time)`,
+}
@@ -0,0 +1,305 @@
+package database
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"health-manager/internal/config"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// boltRollbackPath 还原bolt文件快照前保留的原数据库
+	boltRollbackPath = boltDBPath + ".bak"
+	// logicalRollbackPath 逻辑还原前导出的原数据
+	logicalRollbackPath = "data/restore_rollback.ndjson"
+)
+
+// RestoreDB 从指定路径还原数据库。
+// 逻辑备份适用于所有后端；bolt 后端同时兼容旧版的数据库文件快照。
+// 加密备份需提供 passphrase，先解密到数据目录中的暂存文件再还原。
+// 还原前会完整校验备份文件，并保留原数据库作为回滚副本，失败时自动回滚。
+func (m *Manager) RestoreDB(srcPath, passphrase string) error {
+	encrypted, err := isEncryptedBackup(srcPath)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		plainPath, err := decryptBackupFile(srcPath, passphrase, filepath.Dir(boltDBPath))
+		if err != nil {
+			return err
+		}
+		defer os.Remove(plainPath)
+		srcPath = plainPath
+	}
+
+	logical, err := isLogicalBackup(srcPath)
+	if err != nil {
+		return err
+	}
+	if logical {
+		return m.restoreLogical(srcPath)
+	}
+
+	if t := m.Config().Type; t != "" && t != config.TypeBolt {
+		return fmt.Errorf("%s数据库只能从逻辑备份还原", t)
+	}
+	return m.restoreBoltFile(srcPath)
+}
+
+// isLogicalBackup 根据文件首个非空白字符判断是否为逻辑备份（JSON）
+func isLogicalBackup(srcPath string) (bool, error) {
+	f, err := os.Open(srcPath)
+	if os.IsNotExist(err) {
+		return false, fmt.Errorf("备份文件不存在: %s", srcPath)
+	}
+	if err != nil {
+		return false, fmt.Errorf("无法打开备份文件: %v", err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return false, nil
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '{', nil
+	}
+}
+
+// ========== 逻辑备份还原 ==========
+
+// txStore 可在一个事务中执行多步操作的存储（SQL 后端）
+type txStore interface {
+	runInTx(fn func(tx Store) error) error
+}
+
+// restoreLogical 清空当前数据库并导入逻辑备份。
+// SQL 后端在一个事务中完成清空与导入，失败时整体回滚；bolt 后端导入失败时从回滚副本恢复
+func (m *Manager) restoreLogical(srcPath string) error {
+	// 先完整校验一遍，避免清空数据后才发现文件损坏
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("无法打开备份文件: %v", err)
+	}
+	_, counts, err := readBackup(f, nil)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if counts.Users == 0 {
+		return fmt.Errorf("备份中没有任何用户")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	if ts, ok := m.store.(txStore); ok {
+		if err := ts.runInTx(func(tx Store) error { return importBackupFile(tx, srcPath) }); err != nil {
+			return fmt.Errorf("%v（已回滚，原数据未改动）", err)
+		}
+		return nil
+	}
+
+	// 保留原数据作为回滚副本
+	if err := writeBackupFile(m.store, m.cfg.Type, logicalRollbackPath); err != nil {
+		return fmt.Errorf("保存回滚副本失败: %v", err)
+	}
+
+	if err := importBackupFile(m.store, srcPath); err != nil {
+		log.Printf("还原失败，正在从 %s 回滚: %v", logicalRollbackPath, err)
+		if rbErr := importBackupFile(m.store, logicalRollbackPath); rbErr != nil {
+			return fmt.Errorf("%v；回滚也失败: %v，原数据保存在 %s", err, rbErr, logicalRollbackPath)
+		}
+		return fmt.Errorf("%v（已回滚到还原前的数据）", err)
+	}
+	return nil
+}
+
+// writeBackupFile 将store导出为逻辑备份文件
+func writeBackupFile(store Store, source, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := ExportBackup(store, source, f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// importBackupFile 清空store后导入逻辑备份文件。备份中不含凭据设置，导入前后保留当前的值
+func importBackupFile(store Store, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	secrets := map[string]string{}
+	store.ExportSettings(func(key, value string) error {
+		if IsSecretSetting(key) {
+			secrets[key] = value
+		}
+		return nil
+	})
+	defer func() {
+		for key, value := range secrets {
+			if err := store.SetSetting(key, value); err != nil {
+				log.Printf("恢复设置 %s 失败: %v", key, err)
+			}
+		}
+	}()
+
+	if err := store.ClearData(); err != nil {
+		return fmt.Errorf("清空数据失败: %v", err)
+	}
+	if _, err := importBackup(store, f); err != nil {
+		return fmt.Errorf("导入数据失败: %v", err)
+	}
+	return nil
+}
+
+// ========== bolt文件快照还原 ==========
+
+// restoreBoltFile 暂存并校验bolt文件快照，通过后原子替换当前数据库
+func (m *Manager) restoreBoltFile(srcPath string) error {
+	// 在数据目录中暂存，保证之后的 rename 在同一文件系统内是原子的
+	staged, err := os.CreateTemp(filepath.Dir(boltDBPath), "restore-*.db")
+	if err != nil {
+		return fmt.Errorf("无法创建暂存文件: %v", err)
+	}
+	stagedPath := staged.Name()
+	staged.Close()
+	defer os.Remove(stagedPath)
+
+	if err := copyFile(srcPath, stagedPath); err != nil {
+		return err
+	}
+	if err := validateBoltFile(stagedPath); err != nil {
+		return fmt.Errorf("备份文件校验失败: %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.store != nil {
+		m.store.Close()
+		m.store = nil
+	}
+
+	// 保留原数据库作为回滚副本，再将暂存文件替换到位
+	if err := os.Rename(boltDBPath, boltRollbackPath); err != nil && !os.IsNotExist(err) {
+		m.reopen()
+		return fmt.Errorf("无法保留原数据库: %v", err)
+	}
+	if err := os.Rename(stagedPath, boltDBPath); err != nil {
+		os.Rename(boltRollbackPath, boltDBPath)
+		m.reopen()
+		return fmt.Errorf("替换数据库文件失败: %v", err)
+	}
+
+	store, err := Open(m.cfg)
+	if err != nil {
+		log.Printf("打开还原后的数据库失败，正在回滚: %v", err)
+		os.Rename(boltRollbackPath, boltDBPath)
+		m.reopen()
+		return fmt.Errorf("打开还原后的数据库失败，已回滚: %v", err)
+	}
+	m.store = store
+	log.Printf("数据库已还原，原数据库保存在 %s", boltRollbackPath)
+	return nil
+}
+
+// reopen 重新打开当前配置的数据库（调用方需持有写锁）
+func (m *Manager) reopen() {
+	store, err := Open(m.cfg)
+	if err != nil {
+		log.Printf("重新打开数据库失败: %v", err)
+		return
+	}
+	m.store = store
+}
+
+// validateBoltFile 以只读方式打开bolt文件，检查必需的bucket、结构版本以及是否存在有效用户
+func validateBoltFile(path string) error {
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("不是有效的数据库文件: %v", err)
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		users := tx.Bucket(usersBucket)
+		if users == nil || tx.Bucket(bpBucket) == nil {
+			return fmt.Errorf("缺少用户或健康记录数据")
+		}
+
+		if meta := tx.Bucket(metaBucket); meta != nil {
+			if v := meta.Get([]byte(schemaVersionKey)); v != nil {
+				version, err := strconv.Atoi(string(v))
+				if err != nil {
+					return fmt.Errorf("结构版本号无效: %q", v)
+				}
+				if version > latestSchemaVersion() {
+					return fmt.Errorf("数据库结构版本(%d)高于当前程序支持的版本(%d)", version, latestSchemaVersion())
+				}
+			}
+		}
+
+		count := 0
+		err := users.ForEach(func(k, v []byte) error {
+			var u User
+			if err := json.Unmarshal(v, &u); err != nil || u.Username == "" {
+				return fmt.Errorf("用户数据损坏")
+			}
+			count++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("备份中没有任何用户")
+		}
+		return nil
+	})
+}
+
+// copyFile 复制文件
+func copyFile(srcPath, destPath string) error {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("无法打开备份文件: %v", err)
+	}
+	defer srcFile.Close()
+
+	destFile, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("无法创建数据库文件: %v", err)
+	}
+	defer destFile.Close()
+
+	if _, err := io.Copy(destFile, srcFile); err != nil {
+		return fmt.Errorf("复制文件失败: %v", err)
+	}
+	return destFile.Close()
+}
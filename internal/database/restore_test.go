@@ -0,0 +1,166 @@
+package database
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"health-manager/internal/config"
+)
+
+// restoreFixture 源库中 alice 的数据导出为备份文件，目标库中有 bob 的数据和一项凭据设置
+type restoreFixture struct {
+	src, dst Store
+	m        *Manager
+	backup   string
+	at       time.Time
+}
+
+// newRestoreFixtures 为每种后端准备还原测试的数据。bolt 还原时在 data/ 中写回滚副本，因此切换到临时目录
+func newRestoreFixtures(t *testing.T) map[string]*restoreFixture {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Mkdir("data", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2024, 3, 5, 7, 30, 0, 0, time.UTC)
+	srcs, dsts := testStores(t), testStores(t)
+	fixtures := map[string]*restoreFixture{}
+	for name, src := range srcs {
+		dst := dsts[name]
+		seedUserData(t, src, mustUserID(t, src, "alice"), at)
+		if err := src.SetSetting("backup_schedule", "0 3 * * *"); err != nil {
+			t.Fatal(err)
+		}
+		seedUserData(t, dst, mustUserID(t, dst, "bob"), at)
+		if err := dst.SetSetting(SecretSettingPrefix+"smtp_password", "hunter2"); err != nil {
+			t.Fatal(err)
+		}
+
+		backup := filepath.Join(dir, name+".ndjson")
+		if err := writeBackupFile(src, name, backup); err != nil {
+			t.Fatal(err)
+		}
+		fixtures[name] = &restoreFixture{src: src, dst: dst, m: NewManager(dst, &config.DBConfig{Type: name}), backup: backup, at: at}
+	}
+	return fixtures
+}
+
+// appendEntries 在备份文件末尾追加条目
+func appendEntries(t *testing.T, path string, entries ...backupEntry) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkCounts 比较用户的各类数据条数
+func checkCounts(t *testing.T, s Store, userID int64, want map[string]int) {
+	t.Helper()
+	got := userRowCounts(t, s, userID)
+	for kind, n := range want {
+		if got[kind] != n {
+			t.Errorf("%s = %d, want %d", kind, got[kind], n)
+		}
+	}
+}
+
+func TestRestoreLogical(t *testing.T) {
+	for name, f := range newRestoreFixtures(t) {
+		t.Run(name, func(t *testing.T) {
+			alice, err := f.src.GetUserByUsername("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := userRowCounts(t, f.src, alice.ID)
+
+			if err := f.m.RestoreDB(f.backup, ""); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := f.dst.GetUserByUsername("bob"); err == nil {
+				t.Error("user bob still exists after restore")
+			}
+			restored, err := f.dst.GetUserByUsername("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored.ID != alice.ID || restored.Password != alice.Password {
+				t.Errorf("restored user = %+v, want %+v", restored, alice)
+			}
+			checkCounts(t, f.dst, alice.ID, want)
+
+			records, err := f.dst.GetBPRecords(alice.ID, time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 || records[0].Systolic != 128 || !records[0].RecordTime.Equal(f.at) {
+				t.Errorf("restored records = %+v", records)
+			}
+			if v, _ := f.dst.GetSetting("backup_schedule"); v != "0 3 * * *" {
+				t.Errorf("backup_schedule = %q", v)
+			}
+			// 备份中不含凭据设置，还原后保留当前的值
+			if v, _ := f.dst.GetSetting(SecretSettingPrefix + "smtp_password"); v != "hunter2" {
+				t.Errorf("secret setting = %q, want it kept", v)
+			}
+
+			// 还原后新建的数据不与导入的ID冲突
+			id, err := f.dst.CreateBPRecord(&BloodPressure{UserID: alice.ID, Systolic: 120, Diastolic: 80, RecordTime: f.at})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id <= records[0].ID {
+				t.Errorf("new record id = %d, want > %d", id, records[0].ID)
+			}
+		})
+	}
+}
+
+func TestRestoreLogicalRollback(t *testing.T) {
+	for name, f := range newRestoreFixtures(t) {
+		t.Run(name, func(t *testing.T) {
+			bob, err := f.dst.GetUserByUsername("bob")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := userRowCounts(t, f.dst, bob.ID)
+
+			// 格式合法但导入到一半会失败：重复的ID违反 SQL 主键，空用户名无法写入 bolt 的用户名索引
+			appendEntries(t, f.backup,
+				backupEntry{Type: backupEntryUser, User: &User{ID: 100, Username: "carol", Role: "user"}},
+				backupEntry{Type: backupEntryUser, User: &User{ID: 100, Username: "carol", Role: "user"}},
+				backupEntry{Type: backupEntryUser, User: &User{ID: 101, Role: "user"}},
+			)
+
+			if err := f.m.RestoreDB(f.backup, ""); err == nil {
+				t.Fatal("restore succeeded, want import error")
+			}
+
+			for _, username := range []string{"alice", "carol"} {
+				if _, err := f.dst.GetUserByUsername(username); err == nil {
+					t.Errorf("user %s exists after failed restore", username)
+				}
+			}
+			if u, err := f.dst.GetUserByUsername("bob"); err != nil || u.ID != bob.ID {
+				t.Fatalf("bob after failed restore = %+v, %v", u, err)
+			}
+			checkCounts(t, f.dst, bob.ID, want)
+			if v, _ := f.dst.GetSetting(SecretSettingPrefix + "smtp_password"); v != "hunter2" {
+				t.Errorf("secret setting = %q, want it kept", v)
+			}
+		})
+	}
+}
@@ -0,0 +1,1097 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dialect SQL方言，封装不同数据库之间的语法差异
+type dialect string
+
+const (
+	dialectMySQL    dialect = "mysql"
+	dialectSQLite   dialect = "sqlite"
+	dialectPostgres dialect = "postgres"
+)
+
+// rebind 将 ? 占位符转换为目标数据库的格式（PostgreSQL 使用 $1, $2 ...）
+func (d dialect) rebind(query string) string {
+	if d != dialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// timeArg 转换写入的时间参数。MySQL 驱动按 loc=Local 写入本地时间，
+// PostgreSQL 驱动写入 timestamp 时直接丢弃时区，这里先转为本地时间保持一致。
+// SQLite 以文本保存时间，统一转为UTC后文本顺序即时间顺序，范围查询可直接比较字段并使用索引。
+func (d dialect) timeArg(t time.Time) time.Time {
+	switch d {
+	case dialectPostgres:
+		return t.In(time.Local)
+	case dialectSQLite:
+		return t.UTC()
+	}
+	return t
+}
+
+// scanTime 修正读出的时间。PostgreSQL 驱动把 timestamp 读成 UTC，
+// 实际存储的是本地时间，需重新标记为本地时区；SQLite 存储的UTC时间转为本地时间，与其他数据库一致。
+func (d dialect) scanTime(t time.Time) time.Time {
+	switch d {
+	case dialectPostgres:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+	case dialectSQLite:
+		return t.In(time.Local)
+	}
+	return t
+}
+
+// upsertSetting 返回写入或更新设置项的语句
+func (d dialect) upsertSetting() string {
+	if d == dialectSQLite || d == dialectPostgres {
+		return "INSERT INTO settings (setting_key, setting_value) VALUES (?, ?) ON CONFLICT(setting_key) DO UPDATE SET setting_value = excluded.setting_value"
+	}
+	return "INSERT INTO settings (setting_key, setting_value) VALUES (?, ?) ON DUPLICATE KEY UPDATE setting_value = VALUES(setting_value)"
+}
+
+// upsertUserSetting 返回写入或更新用户设置项的语句
+func (d dialect) upsertUserSetting() string {
+	if d == dialectSQLite || d == dialectPostgres {
+		return "INSERT INTO user_settings (user_id, setting_key, setting_value) VALUES (?, ?, ?) ON CONFLICT(user_id, setting_key) DO UPDATE SET setting_value = excluded.setting_value"
+	}
+	return "INSERT INTO user_settings (user_id, setting_key, setting_value) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE setting_value = VALUES(setting_value)"
+}
+
+// syncSequence 显式写入ID后同步自增序列。MySQL/SQLite 会自动调整，PostgreSQL 需手动 setval
+func (d dialect) syncSequence(table string) string {
+	if d != dialectPostgres {
+		return ""
+	}
+	return fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), (SELECT MAX(id) FROM %s))", table, table)
+}
+
+// sqlConn *sql.DB 与 *sql.Tx 共有的查询方法
+type sqlConn interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// sqlStore 基于database/sql的存储实现
+type sqlStore struct {
+	db      *sql.DB
+	tx      *sql.Tx // 不为 nil 时所有操作都在该事务中执行，见 inTx
+	dialect dialect
+}
+
+// conn 返回当前事务，不在事务中时返回连接池
+func (s *sqlStore) conn() sqlConn {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// inTx 在一个事务中执行fn，fn 返回错误时回滚。已在事务中时直接复用当前事务
+func (s *sqlStore) inTx(fn func(tx *sqlStore) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&sqlStore{db: s.db, tx: tx, dialect: s.dialect}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// runInTx 在一个事务中执行fn，fn 中通过传入的Store进行的操作随事务一起提交或回滚
+func (s *sqlStore) runInTx(fn func(tx Store) error) error {
+	return s.inTx(func(tx *sqlStore) error { return fn(tx) })
+}
+
+// Close 关闭数据库连接
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqlStore) exec(query string, args ...interface{}) (sql.Result, error) {
+	return s.conn().Exec(s.dialect.rebind(query), args...)
+}
+
+func (s *sqlStore) query(query string, args ...interface{}) (*sql.Rows, error) {
+	return s.conn().Query(s.dialect.rebind(query), args...)
+}
+
+func (s *sqlStore) queryRow(query string, args ...interface{}) *sql.Row {
+	return s.conn().QueryRow(s.dialect.rebind(query), args...)
+}
+
+// insert 执行插入并返回自增ID（PostgreSQL 不支持 LastInsertId，改用 RETURNING）
+func (s *sqlStore) insert(query string, args ...interface{}) (int64, error) {
+	if s.dialect == dialectPostgres {
+		var id int64
+		err := s.queryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+	result, err := s.exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ========== 用户操作 ==========
+
+// GetUserByUsername 根据用户名获取用户
+func (s *sqlStore) GetUserByUsername(username string) (*User, error) {
+	var user User
+	var timeZone, email sql.NullString
+	err := s.queryRow("SELECT id, username, password, role, time_zone, email, created_at FROM users WHERE username = ?",
+		username).Scan(&user.ID, &user.Username, &user.Password, &user.Role, &timeZone, &email, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	user.TimeZone = timeZone.String
+	user.Email = email.String
+	user.CreatedAt = s.dialect.scanTime(user.CreatedAt)
+	return &user, nil
+}
+
+// GetUserByID 根据ID获取用户
+func (s *sqlStore) GetUserByID(id int64) (*User, error) {
+	var user User
+	var timeZone, email sql.NullString
+	err := s.queryRow("SELECT id, username, password, role, time_zone, email, created_at FROM users WHERE id = ?",
+		id).Scan(&user.ID, &user.Username, &user.Password, &user.Role, &timeZone, &email, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	user.TimeZone = timeZone.String
+	user.Email = email.String
+	user.CreatedAt = s.dialect.scanTime(user.CreatedAt)
+	return &user, nil
+}
+
+// GetAllUsers 获取所有用户
+func (s *sqlStore) GetAllUsers() ([]User, error) {
+	rows, err := s.query("SELECT id, username, role, time_zone, email, created_at FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		var timeZone, email sql.NullString
+		if err := rows.Scan(&u.ID, &u.Username, &u.Role, &timeZone, &email, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		u.TimeZone = timeZone.String
+		u.Email = email.String
+		u.CreatedAt = s.dialect.scanTime(u.CreatedAt)
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// CreateUser 创建用户
+func (s *sqlStore) CreateUser(username, hashedPassword, role string) error {
+	_, err := s.exec("INSERT INTO users (username, password, role) VALUES (?, ?, ?)",
+		username, hashedPassword, role)
+	return err
+}
+
+// DeleteUser 在一个事务中删除用户及其全部数据，任一步失败时整体回滚
+func (s *sqlStore) DeleteUser(id int64) error {
+	return s.inTx(func(tx *sqlStore) error {
+		for _, query := range []string{
+			"DELETE FROM blood_pressure WHERE user_id = ?",
+			"DELETE FROM measurements WHERE user_id = ?",
+			"DELETE FROM user_settings WHERE user_id = ?",
+			"DELETE FROM medication_intakes WHERE user_id = ?",
+			"DELETE FROM medications WHERE user_id = ?",
+			"DELETE FROM alerts WHERE user_id = ?",
+			"DELETE FROM users WHERE id = ?",
+		} {
+			if _, err := tx.exec(query, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateUserPassword 更新用户密码
+func (s *sqlStore) UpdateUserPassword(id int64, hashedPassword string) error {
+	_, err := s.exec("UPDATE users SET password = ? WHERE id = ?", hashedPassword, id)
+	return err
+}
+
+// GetUserRole 获取用户角色
+func (s *sqlStore) GetUserRole(id int64) string {
+	var role string
+	s.queryRow("SELECT role FROM users WHERE id = ?", id).Scan(&role)
+	return role
+}
+
+// CountAdmins 统计管理员数量
+func (s *sqlStore) CountAdmins() int {
+	var count int
+	s.queryRow("SELECT COUNT(*) FROM users WHERE role = 'admin'").Scan(&count)
+	return count
+}
+
+// UpdateUserRole 更新用户角色
+func (s *sqlStore) UpdateUserRole(id int64, role string) error {
+	_, err := s.exec("UPDATE users SET role = ? WHERE id = ?", role, id)
+	return err
+}
+
+// GetUserTimeZone 获取用户时区
+func (s *sqlStore) GetUserTimeZone(id int64) string {
+	var tz sql.NullString
+	s.queryRow("SELECT time_zone FROM users WHERE id = ?", id).Scan(&tz)
+	return tz.String
+}
+
+// UpdateUserTimeZone 更新用户时区
+func (s *sqlStore) UpdateUserTimeZone(id int64, timeZone string) error {
+	_, err := s.exec("UPDATE users SET time_zone = ? WHERE id = ?", timeZone, id)
+	return err
+}
+
+// GetUserEmail 获取用户邮箱
+func (s *sqlStore) GetUserEmail(id int64) string {
+	var email sql.NullString
+	s.queryRow("SELECT email FROM users WHERE id = ?", id).Scan(&email)
+	return email.String
+}
+
+// UpdateUserEmail 更新用户邮箱
+func (s *sqlStore) UpdateUserEmail(id int64, email string) error {
+	_, err := s.exec("UPDATE users SET email = ? WHERE id = ?", email, id)
+	return err
+}
+
+// ========== 健康记录操作 ==========
+
+// CreateBPRecord 创建健康记录
+func (s *sqlStore) CreateBPRecord(bp *BloodPressure) (int64, error) {
+	bp.CreatedAt = time.Now()
+	id, err := s.insert(`INSERT INTO blood_pressure (user_id, systolic, diastolic, heart_rate, height, weight, waistline, record_time, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, bp.UserID, bp.Systolic, bp.Diastolic, bp.HeartRate, bp.Height, bp.Weight, bp.Waistline,
+		s.dialect.timeArg(bp.RecordTime), bp.Notes, s.dialect.timeArg(bp.CreatedAt))
+	if err != nil {
+		return 0, err
+	}
+	bp.ID = id
+	return bp.ID, nil
+}
+
+// GetBPRecords 获取记录时间在 [start, end) 内的健康记录，按时间倒序
+func (s *sqlStore) GetBPRecords(userID int64, start, end time.Time) ([]BloodPressure, error) {
+	query := "SELECT id, systolic, diastolic, heart_rate, height, weight, waistline, record_time, notes, created_at, updated_at FROM blood_pressure WHERE user_id = ?"
+	args := []interface{}{userID}
+
+	query, args = s.timeRange(query, args, "record_time", start, end)
+	query += " ORDER BY record_time DESC"
+
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []BloodPressure
+	for rows.Next() {
+		var bp BloodPressure
+		var notes sql.NullString
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&bp.ID, &bp.Systolic, &bp.Diastolic, &bp.HeartRate, &bp.Height, &bp.Weight, &bp.Waistline, &bp.RecordTime, &notes, &bp.CreatedAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		s.fixRecord(&bp, notes, updatedAt)
+		bp.UserID = userID
+		records = append(records, bp)
+	}
+	return records, rows.Err()
+}
+
+// GetBPRecord 获取用户的单条健康记录
+func (s *sqlStore) GetBPRecord(id, userID int64) (*BloodPressure, error) {
+	var bp BloodPressure
+	var notes sql.NullString
+	var updatedAt sql.NullTime
+	err := s.queryRow(`SELECT id, user_id, systolic, diastolic, heart_rate, height, weight, waistline, record_time, notes, created_at, updated_at
+		FROM blood_pressure WHERE id = ? AND user_id = ?`, id, userID).Scan(&bp.ID, &bp.UserID, &bp.Systolic, &bp.Diastolic, &bp.HeartRate,
+		&bp.Height, &bp.Weight, &bp.Waistline, &bp.RecordTime, &notes, &bp.CreatedAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("record not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.fixRecord(&bp, notes, updatedAt)
+	return &bp, nil
+}
+
+// UpdateBPRecord 修改健康记录（按ID与所属用户匹配），并记录修改时间
+func (s *sqlStore) UpdateBPRecord(bp *BloodPressure) error {
+	now := time.Now()
+	result, err := s.exec(`UPDATE blood_pressure SET systolic = ?, diastolic = ?, heart_rate = ?, height = ?, weight = ?, waistline = ?,
+		record_time = ?, notes = ?, updated_at = ? WHERE id = ? AND user_id = ?`, bp.Systolic, bp.Diastolic, bp.HeartRate, bp.Height, bp.Weight,
+		bp.Waistline, s.dialect.timeArg(bp.RecordTime), bp.Notes, s.dialect.timeArg(now), bp.ID, bp.UserID)
+	if err != nil {
+		return err
+	}
+	// MySQL 在值未变化时影响行数为0，需再确认记录是否存在
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		if _, err := s.GetBPRecord(bp.ID, bp.UserID); err != nil {
+			return err
+		}
+	}
+	bp.UpdatedAt = &now
+	return nil
+}
+
+// fixRecord 处理可空字段并修正读出的时间
+func (s *sqlStore) fixRecord(bp *BloodPressure, notes sql.NullString, updatedAt sql.NullTime) {
+	bp.Notes = notes.String
+	bp.RecordTime = s.dialect.scanTime(bp.RecordTime)
+	bp.CreatedAt = s.dialect.scanTime(bp.CreatedAt)
+	if updatedAt.Valid {
+		t := s.dialect.scanTime(updatedAt.Time)
+		bp.UpdatedAt = &t
+	}
+}
+
+// DeleteBPRecord 删除血压记录
+func (s *sqlStore) DeleteBPRecord(id, userID int64) error {
+	result, err := s.exec("DELETE FROM blood_pressure WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("record not found")
+	}
+	return nil
+}
+
+// ========== 通用测量记录 ==========
+
+// measurementColumns 通用测量记录的查询字段，与 scanMeasurement 对应
+const measurementColumns = "id, user_id, measure_type, measure_values, unit, context, record_time, notes, created_at, updated_at"
+
+// rowScanner 兼容 *sql.Row 与 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMeasurement 读取一行通用测量记录并解析数值JSON
+func (s *sqlStore) scanMeasurement(row rowScanner) (Measurement, error) {
+	var m Measurement
+	var values string
+	var unit, context, notes sql.NullString
+	var updatedAt sql.NullTime
+	if err := row.Scan(&m.ID, &m.UserID, &m.Type, &values, &unit, &context, &m.RecordTime, &notes, &m.CreatedAt, &updatedAt); err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal([]byte(values), &m.Values); err != nil {
+		return m, fmt.Errorf("测量记录 %d 数值损坏: %v", m.ID, err)
+	}
+	m.Unit = unit.String
+	m.Context = context.String
+	m.Notes = notes.String
+	m.RecordTime = s.dialect.scanTime(m.RecordTime)
+	m.CreatedAt = s.dialect.scanTime(m.CreatedAt)
+	if updatedAt.Valid {
+		t := s.dialect.scanTime(updatedAt.Time)
+		m.UpdatedAt = &t
+	}
+	return m, nil
+}
+
+// CreateMeasurement 创建通用测量记录
+func (s *sqlStore) CreateMeasurement(m *Measurement) (int64, error) {
+	m.CreatedAt = time.Now()
+	values, _ := json.Marshal(m.Values)
+	id, err := s.insert(`INSERT INTO measurements (user_id, measure_type, measure_values, unit, context, record_time, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, m.UserID, m.Type, string(values), m.Unit, m.Context,
+		s.dialect.timeArg(m.RecordTime), m.Notes, s.dialect.timeArg(m.CreatedAt))
+	if err != nil {
+		return 0, err
+	}
+	m.ID = id
+	return m.ID, nil
+}
+
+// GetMeasurements 获取记录时间在 [start, end) 内的通用测量记录，typ 为空时返回全部类型，按时间倒序
+func (s *sqlStore) GetMeasurements(userID int64, typ string, start, end time.Time) ([]Measurement, error) {
+	query := "SELECT " + measurementColumns + " FROM measurements WHERE user_id = ?"
+	args := []interface{}{userID}
+
+	if typ != "" {
+		query += " AND measure_type = ?"
+		args = append(args, typ)
+	}
+	query, args = s.timeRange(query, args, "record_time", start, end)
+	query += " ORDER BY record_time DESC, id DESC"
+
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Measurement
+	for rows.Next() {
+		m, err := s.scanMeasurement(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// GetMeasurement 获取用户的单条通用测量记录
+func (s *sqlStore) GetMeasurement(id, userID int64) (*Measurement, error) {
+	m, err := s.scanMeasurement(s.queryRow("SELECT "+measurementColumns+" FROM measurements WHERE id = ? AND user_id = ?", id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("record not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// UpdateMeasurement 修改通用测量记录（按ID与所属用户匹配），并记录修改时间
+func (s *sqlStore) UpdateMeasurement(m *Measurement) error {
+	now := time.Now()
+	values, _ := json.Marshal(m.Values)
+	result, err := s.exec(`UPDATE measurements SET measure_values = ?, unit = ?, context = ?, record_time = ?, notes = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?`, string(values), m.Unit, m.Context, s.dialect.timeArg(m.RecordTime), m.Notes, s.dialect.timeArg(now), m.ID, m.UserID)
+	if err != nil {
+		return err
+	}
+	// MySQL 在值未变化时影响行数为0，需再确认记录是否存在
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		if _, err := s.GetMeasurement(m.ID, m.UserID); err != nil {
+			return err
+		}
+	}
+	m.UpdatedAt = &now
+	return nil
+}
+
+// DeleteMeasurement 删除通用测量记录
+func (s *sqlStore) DeleteMeasurement(id, userID int64) error {
+	result, err := s.exec("DELETE FROM measurements WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("record not found")
+	}
+	return nil
+}
+
+// ========== 用药 ==========
+
+// medicationColumns 药物的查询字段，与 scanMedication 对应
+const medicationColumns = "id, user_id, name, dose, schedule, schedule_times, notes, stopped_at, created_at, updated_at"
+
+// intakeColumns 服药记录的查询字段，与 scanIntake 对应
+const intakeColumns = "id, user_id, medication_id, name, dose, taken_at, notes, created_at"
+
+// timeRange 为查询追加时间字段在 [start, end) 内的条件，零值表示不限
+func (s *sqlStore) timeRange(query string, args []interface{}, column string, start, end time.Time) (string, []interface{}) {
+	if !start.IsZero() {
+		query += " AND " + column + " >= ?"
+		args = append(args, s.dialect.timeArg(start))
+	}
+	if !end.IsZero() {
+		query += " AND " + column + " < ?"
+		args = append(args, s.dialect.timeArg(end))
+	}
+	return query, args
+}
+
+// nullTime 将可空时间转换为写入参数
+func (s *sqlStore) nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return s.dialect.timeArg(*t)
+}
+
+// scanMedication 读取一行药物并解析计划服药时间JSON
+func (s *sqlStore) scanMedication(row rowScanner) (Medication, error) {
+	var med Medication
+	var dose, schedule, times, notes sql.NullString
+	var stoppedAt, updatedAt sql.NullTime
+	if err := row.Scan(&med.ID, &med.UserID, &med.Name, &dose, &schedule, &times, &notes, &stoppedAt, &med.CreatedAt, &updatedAt); err != nil {
+		return med, err
+	}
+	if times.String != "" {
+		if err := json.Unmarshal([]byte(times.String), &med.Times); err != nil {
+			return med, fmt.Errorf("药物 %d 服药时间损坏: %v", med.ID, err)
+		}
+	}
+	med.Dose = dose.String
+	med.Schedule = schedule.String
+	med.Notes = notes.String
+	med.CreatedAt = s.dialect.scanTime(med.CreatedAt)
+	if stoppedAt.Valid {
+		t := s.dialect.scanTime(stoppedAt.Time)
+		med.StoppedAt = &t
+	}
+	if updatedAt.Valid {
+		t := s.dialect.scanTime(updatedAt.Time)
+		med.UpdatedAt = &t
+	}
+	return med, nil
+}
+
+// scanIntake 读取一行服药记录
+func (s *sqlStore) scanIntake(row rowScanner) (MedicationIntake, error) {
+	var in MedicationIntake
+	var dose, notes sql.NullString
+	if err := row.Scan(&in.ID, &in.UserID, &in.MedicationID, &in.Name, &dose, &in.TakenAt, &notes, &in.CreatedAt); err != nil {
+		return in, err
+	}
+	in.Dose = dose.String
+	in.Notes = notes.String
+	in.TakenAt = s.dialect.scanTime(in.TakenAt)
+	in.CreatedAt = s.dialect.scanTime(in.CreatedAt)
+	return in, nil
+}
+
+// CreateMedication 添加药物
+func (s *sqlStore) CreateMedication(med *Medication) (int64, error) {
+	med.CreatedAt = time.Now()
+	times, _ := json.Marshal(med.Times)
+	id, err := s.insert(`INSERT INTO medications (user_id, name, dose, schedule, schedule_times, notes, stopped_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, med.UserID, med.Name, med.Dose, med.Schedule, string(times), med.Notes,
+		s.nullTime(med.StoppedAt), s.dialect.timeArg(med.CreatedAt))
+	if err != nil {
+		return 0, err
+	}
+	med.ID = id
+	return med.ID, nil
+}
+
+// GetMedications 获取用户的全部药物，按ID顺序
+func (s *sqlStore) GetMedications(userID int64) ([]Medication, error) {
+	rows, err := s.query("SELECT "+medicationColumns+" FROM medications WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Medication
+	for rows.Next() {
+		med, err := s.scanMedication(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, med)
+	}
+	return list, rows.Err()
+}
+
+// GetMedication 获取用户的单个药物
+func (s *sqlStore) GetMedication(id, userID int64) (*Medication, error) {
+	med, err := s.scanMedication(s.queryRow("SELECT "+medicationColumns+" FROM medications WHERE id = ? AND user_id = ?", id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("medication not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &med, nil
+}
+
+// UpdateMedication 修改药物（按ID与所属用户匹配），并记录修改时间
+func (s *sqlStore) UpdateMedication(med *Medication) error {
+	now := time.Now()
+	times, _ := json.Marshal(med.Times)
+	result, err := s.exec(`UPDATE medications SET name = ?, dose = ?, schedule = ?, schedule_times = ?, notes = ?, stopped_at = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?`, med.Name, med.Dose, med.Schedule, string(times), med.Notes, s.nullTime(med.StoppedAt),
+		s.dialect.timeArg(now), med.ID, med.UserID)
+	if err != nil {
+		return err
+	}
+	// MySQL 在值未变化时影响行数为0，需再确认记录是否存在
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		if _, err := s.GetMedication(med.ID, med.UserID); err != nil {
+			return err
+		}
+	}
+	med.UpdatedAt = &now
+	return nil
+}
+
+// DeleteMedication 删除药物，已有的服药记录保留
+func (s *sqlStore) DeleteMedication(id, userID int64) error {
+	result, err := s.exec("DELETE FROM medications WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("medication not found")
+	}
+	return nil
+}
+
+// CreateIntake 添加服药记录
+func (s *sqlStore) CreateIntake(in *MedicationIntake) (int64, error) {
+	in.CreatedAt = time.Now()
+	id, err := s.insert(`INSERT INTO medication_intakes (user_id, medication_id, name, dose, taken_at, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, in.UserID, in.MedicationID, in.Name, in.Dose, s.dialect.timeArg(in.TakenAt), in.Notes,
+		s.dialect.timeArg(in.CreatedAt))
+	if err != nil {
+		return 0, err
+	}
+	in.ID = id
+	return in.ID, nil
+}
+
+// GetIntakes 获取服药时间在 [start, end) 内的服药记录，按时间倒序
+func (s *sqlStore) GetIntakes(userID int64, start, end time.Time) ([]MedicationIntake, error) {
+	query := "SELECT " + intakeColumns + " FROM medication_intakes WHERE user_id = ?"
+	args := []interface{}{userID}
+
+	query, args = s.timeRange(query, args, "taken_at", start, end)
+	query += " ORDER BY taken_at DESC, id DESC"
+
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []MedicationIntake
+	for rows.Next() {
+		in, err := s.scanIntake(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, in)
+	}
+	return list, rows.Err()
+}
+
+// DeleteIntake 删除服药记录
+func (s *sqlStore) DeleteIntake(id, userID int64) error {
+	result, err := s.exec("DELETE FROM medication_intakes WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("record not found")
+	}
+	return nil
+}
+
+// ========== 提醒事件 ==========
+
+// alertColumns 提醒事件的查询字段，与 scanAlert 对应
+const alertColumns = "id, user_id, record_id, metric, operator, threshold, value, message, triggered_at, read_at, created_at"
+
+// scanAlert 读取一行提醒事件
+func (s *sqlStore) scanAlert(row rowScanner) (Alert, error) {
+	var a Alert
+	var message sql.NullString
+	var readAt sql.NullTime
+	if err := row.Scan(&a.ID, &a.UserID, &a.RecordID, &a.Metric, &a.Operator, &a.Threshold, &a.Value, &message,
+		&a.TriggeredAt, &readAt, &a.CreatedAt); err != nil {
+		return a, err
+	}
+	a.Message = message.String
+	a.TriggeredAt = s.dialect.scanTime(a.TriggeredAt)
+	a.CreatedAt = s.dialect.scanTime(a.CreatedAt)
+	if readAt.Valid {
+		t := s.dialect.scanTime(readAt.Time)
+		a.ReadAt = &t
+	}
+	return a, nil
+}
+
+// CreateAlert 添加提醒事件
+func (s *sqlStore) CreateAlert(a *Alert) (int64, error) {
+	a.CreatedAt = time.Now()
+	id, err := s.insert(`INSERT INTO alerts (user_id, record_id, metric, operator, threshold, value, message, triggered_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, a.UserID, a.RecordID, a.Metric, a.Operator, a.Threshold, a.Value, a.Message,
+		s.dialect.timeArg(a.TriggeredAt), s.dialect.timeArg(a.CreatedAt))
+	if err != nil {
+		return 0, err
+	}
+	a.ID = id
+	return a.ID, nil
+}
+
+// GetAlerts 获取触发时间在 [start, end) 内的提醒事件，按时间倒序
+func (s *sqlStore) GetAlerts(userID int64, start, end time.Time) ([]Alert, error) {
+	query := "SELECT " + alertColumns + " FROM alerts WHERE user_id = ?"
+	args := []interface{}{userID}
+
+	query, args = s.timeRange(query, args, "triggered_at", start, end)
+	query += " ORDER BY triggered_at DESC, id DESC"
+
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Alert
+	for rows.Next() {
+		a, err := s.scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// MarkAlertRead 将提醒事件标记为已读
+func (s *sqlStore) MarkAlertRead(id, userID int64) error {
+	if _, err := s.scanAlert(s.queryRow("SELECT "+alertColumns+" FROM alerts WHERE id = ? AND user_id = ?", id, userID)); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("alert not found")
+		}
+		return err
+	}
+	_, err := s.exec("UPDATE alerts SET read_at = ? WHERE id = ? AND user_id = ? AND read_at IS NULL",
+		s.dialect.timeArg(time.Now()), id, userID)
+	return err
+}
+
+// ========== 全局设置 ==========
+
+// GetSetting 获取全局设置
+func (s *sqlStore) GetSetting(key string) (string, error) {
+	var value string
+	err := s.queryRow("SELECT setting_value FROM settings WHERE setting_key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetSetting 保存全局设置
+func (s *sqlStore) SetSetting(key, value string) error {
+	_, err := s.exec(s.dialect.upsertSetting(), key, value)
+	return err
+}
+
+// ========== 用户设置 ==========
+
+// GetUserSetting 获取用户设置
+func (s *sqlStore) GetUserSetting(userID int64, key string) (string, error) {
+	var value sql.NullString
+	err := s.queryRow("SELECT setting_value FROM user_settings WHERE user_id = ? AND setting_key = ?", userID, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value.String, err
+}
+
+// SetUserSetting 保存用户设置，value 为空时删除
+func (s *sqlStore) SetUserSetting(userID int64, key, value string) error {
+	if value == "" {
+		_, err := s.exec("DELETE FROM user_settings WHERE user_id = ? AND setting_key = ?", userID, key)
+		return err
+	}
+	_, err := s.exec(s.dialect.upsertUserSetting(), userID, key, value)
+	return err
+}
+
+// ========== 数据导出导入 ==========
+
+// ExportUsers 逐个导出用户（包含密码哈希）
+func (s *sqlStore) ExportUsers(fn func(u User) error) error {
+	rows, err := s.query("SELECT id, username, password, role, time_zone, email, created_at FROM users ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u User
+		var timeZone, email sql.NullString
+		if err := rows.Scan(&u.ID, &u.Username, &u.Password, &u.Role, &timeZone, &email, &u.CreatedAt); err != nil {
+			return err
+		}
+		u.TimeZone = timeZone.String
+		u.Email = email.String
+		u.CreatedAt = s.dialect.scanTime(u.CreatedAt)
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportBPRecords 逐条导出健康记录
+func (s *sqlStore) ExportBPRecords(fn func(bp BloodPressure) error) error {
+	rows, err := s.query(`SELECT id, user_id, systolic, diastolic, heart_rate, height, weight, waistline, record_time, notes, created_at, updated_at
+		FROM blood_pressure ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bp BloodPressure
+		var notes sql.NullString
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&bp.ID, &bp.UserID, &bp.Systolic, &bp.Diastolic, &bp.HeartRate, &bp.Height, &bp.Weight, &bp.Waistline, &bp.RecordTime, &notes, &bp.CreatedAt, &updatedAt); err != nil {
+			return err
+		}
+		s.fixRecord(&bp, notes, updatedAt)
+		if err := fn(bp); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportMeasurements 逐条导出通用测量记录
+func (s *sqlStore) ExportMeasurements(fn func(m Measurement) error) error {
+	rows, err := s.query("SELECT " + measurementColumns + " FROM measurements ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := s.scanMeasurement(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportSettings 逐项导出全局设置
+func (s *sqlStore) ExportSettings(fn func(key, value string) error) error {
+	rows, err := s.query("SELECT setting_key, setting_value FROM settings WHERE setting_key <> ?", schemaVersionKey)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var value sql.NullString
+		if err := rows.Scan(&key, &value); err != nil {
+			return err
+		}
+		if err := fn(key, value.String); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportUserSettings 逐项导出用户设置
+func (s *sqlStore) ExportUserSettings(fn func(userID int64, key, value string) error) error {
+	rows, err := s.query("SELECT user_id, setting_key, setting_value FROM user_settings ORDER BY user_id, setting_key")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID int64
+		var key string
+		var value sql.NullString
+		if err := rows.Scan(&userID, &key, &value); err != nil {
+			return err
+		}
+		if err := fn(userID, key, value.String); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportMedications 逐个导出药物
+func (s *sqlStore) ExportMedications(fn func(med Medication) error) error {
+	rows, err := s.query("SELECT " + medicationColumns + " FROM medications ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		med, err := s.scanMedication(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(med); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportIntakes 逐条导出服药记录
+func (s *sqlStore) ExportIntakes(fn func(in MedicationIntake) error) error {
+	rows, err := s.query("SELECT " + intakeColumns + " FROM medication_intakes ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		in, err := s.scanIntake(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(in); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportAlerts 逐条导出提醒事件
+func (s *sqlStore) ExportAlerts(fn func(a Alert) error) error {
+	rows, err := s.query("SELECT " + alertColumns + " FROM alerts ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a, err := s.scanAlert(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ImportUser 按原ID写入用户
+func (s *sqlStore) ImportUser(u User) error {
+	_, err := s.exec("INSERT INTO users (id, username, password, role, time_zone, email, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		u.ID, u.Username, u.Password, u.Role, u.TimeZone, u.Email, s.dialect.timeArg(u.CreatedAt))
+	return err
+}
+
+// ImportBPRecord 按原ID写入健康记录
+func (s *sqlStore) ImportBPRecord(bp BloodPressure) error {
+	var updatedAt interface{}
+	if bp.UpdatedAt != nil {
+		updatedAt = s.dialect.timeArg(*bp.UpdatedAt)
+	}
+	_, err := s.exec(`INSERT INTO blood_pressure (id, user_id, systolic, diastolic, heart_rate, height, weight, waistline, record_time, notes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, bp.ID, bp.UserID, bp.Systolic, bp.Diastolic, bp.HeartRate, bp.Height, bp.Weight, bp.Waistline,
+		s.dialect.timeArg(bp.RecordTime), bp.Notes, s.dialect.timeArg(bp.CreatedAt), updatedAt)
+	return err
+}
+
+// ImportMeasurement 按原ID写入通用测量记录
+func (s *sqlStore) ImportMeasurement(m Measurement) error {
+	var updatedAt interface{}
+	if m.UpdatedAt != nil {
+		updatedAt = s.dialect.timeArg(*m.UpdatedAt)
+	}
+	values, _ := json.Marshal(m.Values)
+	_, err := s.exec(`INSERT INTO measurements (id, user_id, measure_type, measure_values, unit, context, record_time, notes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, m.ID, m.UserID, m.Type, string(values), m.Unit, m.Context,
+		s.dialect.timeArg(m.RecordTime), m.Notes, s.dialect.timeArg(m.CreatedAt), updatedAt)
+	return err
+}
+
+// ImportMedication 按原ID写入药物
+func (s *sqlStore) ImportMedication(med Medication) error {
+	times, _ := json.Marshal(med.Times)
+	_, err := s.exec(`INSERT INTO medications (id, user_id, name, dose, schedule, schedule_times, notes, stopped_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, med.ID, med.UserID, med.Name, med.Dose, med.Schedule, string(times), med.Notes,
+		s.nullTime(med.StoppedAt), s.dialect.timeArg(med.CreatedAt), s.nullTime(med.UpdatedAt))
+	return err
+}
+
+// ImportIntake 按原ID写入服药记录
+func (s *sqlStore) ImportIntake(in MedicationIntake) error {
+	_, err := s.exec(`INSERT INTO medication_intakes (id, user_id, medication_id, name, dose, taken_at, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, in.ID, in.UserID, in.MedicationID, in.Name, in.Dose,
+		s.dialect.timeArg(in.TakenAt), in.Notes, s.dialect.timeArg(in.CreatedAt))
+	return err
+}
+
+// ImportAlert 按原ID写入提醒事件
+func (s *sqlStore) ImportAlert(a Alert) error {
+	_, err := s.exec(`INSERT INTO alerts (id, user_id, record_id, metric, operator, threshold, value, message, triggered_at, read_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, a.ID, a.UserID, a.RecordID, a.Metric, a.Operator, a.Threshold, a.Value, a.Message,
+		s.dialect.timeArg(a.TriggeredAt), s.nullTime(a.ReadAt), s.dialect.timeArg(a.CreatedAt))
+	return err
+}
+
+// ClearData 清空用户、健康记录、通用测量记录、用药数据、提醒事件、全局设置和用户设置
+func (s *sqlStore) ClearData() error {
+	return s.inTx(func(tx *sqlStore) error {
+		for _, table := range []string{"blood_pressure", "measurements", "user_settings", "medications", "medication_intakes", "alerts", "users"} {
+			if _, err := tx.exec("DELETE FROM " + table); err != nil {
+				return err
+			}
+		}
+		// 保留结构版本号
+		_, err := tx.exec("DELETE FROM settings WHERE setting_key <> ?", schemaVersionKey)
+		return err
+	})
+}
+
+// finishImport 按原ID导入后同步各表的自增序列，每张表只需执行一次
+func (s *sqlStore) finishImport() error {
+	for _, table := range []string{"users", "blood_pressure", "measurements", "medications", "medication_intakes", "alerts"} {
+		if q := s.dialect.syncSequence(table); q != "" {
+			if _, err := s.exec(q); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ========== 数据库备份 ==========
+
+// Backup SQL数据库不支持文件快照，请使用与数据库无关的逻辑备份（ExportBackup）
+func (s *sqlStore) Backup(w io.Writer) error {
+	return fmt.Errorf("%s数据库不支持文件快照，请使用逻辑备份", s.dialect)
+}
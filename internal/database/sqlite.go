@@ -0,0 +1,87 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"os"
+
+	"health-manager/internal/config"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteDBPath = "data/health_manager.sqlite"
+
+// connectSQLite 连接默认位置的SQLite数据库
+func connectSQLite() (*sqlStore, error) {
+	return openSQLite(sqliteDBPath)
+}
+
+// openSQLite 打开指定路径的SQLite数据库（纯Go驱动，无需CGO）并执行结构迁移
+func openSQLite(dbPath string) (*sqlStore, error) {
+	dsn := "file:" + dbPath + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite同一时刻只允许一个写入者，单连接可避免 database is locked
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrateSQL(db, dialectSQLite); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlStore{db: db, dialect: dialectSQLite}, nil
+}
+
+// sqliteSchema SQLite初始表结构（迁移版本1）
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username VARCHAR(50) UNIQUE NOT NULL,
+		password VARCHAR(255) NOT NULL,
+		role VARCHAR(20) DEFAULT 'user',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS blood_pressure (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		systolic INTEGER,
+		diastolic INTEGER,
+		heart_rate INTEGER,
+		height DECIMAL(5,2) DEFAULT 0,
+		weight DECIMAL(5,2) DEFAULT 0,
+		waistline DECIMAL(5,2) DEFAULT 0,
+		record_time DATETIME NOT NULL,
+		notes TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_bp_user_time ON blood_pressure (user_id, record_time)`,
+}
+
+// normalizeLegacyType 兼容旧版本配置：旧版本中 "sqlite" 实际指bbolt文件。
+// 若SQLite文件尚不存在而bbolt文件存在，则视为旧配置并改回bolt，避免数据"丢失"。
+func normalizeLegacyType(cfg *config.DBConfig) *config.DBConfig {
+	if cfg.Type != config.TypeSQLite {
+		return cfg
+	}
+	if _, err := os.Stat(sqliteDBPath); err == nil {
+		return cfg
+	}
+	if _, err := os.Stat(boltDBPath); err != nil {
+		return cfg
+	}
+
+	log.Printf("检测到旧版配置 type=sqlite 且存在 %s，按 bolt 数据库打开", boltDBPath)
+	legacy := *cfg
+	legacy.Type = config.TypeBolt
+	if err := config.SetConfig(&legacy); err != nil {
+		log.Printf("更新数据库配置失败: %v", err)
+	}
+	return &legacy
+}
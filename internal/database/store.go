@@ -0,0 +1,117 @@
+package database
+
+import (
+	"io"
+	"time"
+)
+
+// User 用户结构
+type User struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password"`
+	Role      string    `json:"role"`
+	TimeZone  string    `json:"time_zone"` // IANA时区名，为空时使用服务器默认时区
+	Email     string    `json:"email"`     // 接收提醒邮件的地址，为空表示不发送邮件
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BloodPressure 健康记录结构（包含血压和身高体重）
+type BloodPressure struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Systolic   int        `json:"systolic"`
+	Diastolic  int        `json:"diastolic"`
+	HeartRate  int        `json:"heart_rate"`
+	Height     float64    `json:"height"`
+	Weight     float64    `json:"weight"`
+	Waistline  float64    `json:"waistline"`
+	RecordTime time.Time  `json:"record_time"`
+	Notes      string     `json:"notes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"` // 最后修改时间，未修改过为空
+}
+
+// Store 数据存储接口，每种数据库后端各自实现一份
+type Store interface {
+	// 用户操作
+	GetUserByUsername(username string) (*User, error)
+	GetUserByID(id int64) (*User, error)
+	GetAllUsers() ([]User, error)
+	CreateUser(username, hashedPassword, role string) error
+	DeleteUser(id int64) error
+	UpdateUserPassword(id int64, hashedPassword string) error
+	GetUserRole(id int64) string
+	CountAdmins() int
+	UpdateUserRole(id int64, role string) error
+	GetUserTimeZone(id int64) string
+	UpdateUserTimeZone(id int64, timeZone string) error
+	GetUserEmail(id int64) string
+	UpdateUserEmail(id int64, email string) error
+
+	// 健康记录操作
+	CreateBPRecord(bp *BloodPressure) (int64, error)
+	// GetBPRecords 返回记录时间在 [start, end) 内的记录（按时间倒序），零值表示不限
+	GetBPRecords(userID int64, start, end time.Time) ([]BloodPressure, error)
+	GetBPRecord(id, userID int64) (*BloodPressure, error)
+	UpdateBPRecord(bp *BloodPressure) error
+	DeleteBPRecord(id, userID int64) error
+
+	// 通用测量记录操作（血压、心率、身高、体重、腰围仍保存在健康记录中，见 MeasurementsFromBP）
+	CreateMeasurement(m *Measurement) (int64, error)
+	// GetMeasurements 返回记录时间在 [start, end) 内的记录（按时间倒序），typ 为空表示全部类型
+	GetMeasurements(userID int64, typ string, start, end time.Time) ([]Measurement, error)
+	GetMeasurement(id, userID int64) (*Measurement, error)
+	UpdateMeasurement(m *Measurement) error
+	DeleteMeasurement(id, userID int64) error
+
+	// 用药操作：药物列表与服药记录
+	CreateMedication(med *Medication) (int64, error)
+	// GetMedications 返回用户的全部药物（含已停用），按ID顺序
+	GetMedications(userID int64) ([]Medication, error)
+	GetMedication(id, userID int64) (*Medication, error)
+	UpdateMedication(med *Medication) error
+	DeleteMedication(id, userID int64) error
+	CreateIntake(in *MedicationIntake) (int64, error)
+	// GetIntakes 返回服药时间在 [start, end) 内的服药记录（按时间倒序），零值表示不限
+	GetIntakes(userID int64, start, end time.Time) ([]MedicationIntake, error)
+	DeleteIntake(id, userID int64) error
+
+	// 提醒事件操作
+	CreateAlert(a *Alert) (int64, error)
+	// GetAlerts 返回触发时间在 [start, end) 内的提醒事件（按时间倒序），零值表示不限
+	GetAlerts(userID int64, start, end time.Time) ([]Alert, error)
+	// MarkAlertRead 将提醒事件标记为已读，已读的事件保持原已读时间
+	MarkAlertRead(id, userID int64) error
+
+	// 全局设置
+	GetSetting(key string) (string, error)
+	SetSetting(key, value string) error
+
+	// 用户设置（按用户保存的个性化配置），value 为空时删除该项
+	GetUserSetting(userID int64, key string) (string, error)
+	SetUserSetting(userID int64, key, value string) error
+
+	// 数据导出导入（用于切换数据库时迁移数据），导入时保留原ID与时间
+	ExportUsers(fn func(u User) error) error
+	ExportBPRecords(fn func(bp BloodPressure) error) error
+	ExportMeasurements(fn func(m Measurement) error) error
+	ExportSettings(fn func(key, value string) error) error
+	ExportUserSettings(fn func(userID int64, key, value string) error) error
+	ExportMedications(fn func(med Medication) error) error
+	ExportIntakes(fn func(in MedicationIntake) error) error
+	ExportAlerts(fn func(a Alert) error) error
+	ImportUser(u User) error
+	ImportBPRecord(bp BloodPressure) error
+	ImportMeasurement(m Measurement) error
+	ImportMedication(med Medication) error
+	ImportIntake(in MedicationIntake) error
+	ImportAlert(a Alert) error
+	// ClearData 清空用户、健康记录、通用测量记录、用药数据、提醒事件、全局设置和用户设置（保留表结构与结构版本）
+	ClearData() error
+
+	// Backup 将数据库快照写入w
+	Backup(w io.Writer) error
+	// Close 关闭底层连接
+	Close() error
+}
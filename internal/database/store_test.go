@@ -0,0 +1,219 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStores 在临时目录中打开 bolt 和 SQLite 存储，测试结束时关闭
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	dir := t.TempDir()
+	bs, err := openBolt(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, err := openSQLite(filepath.Join(dir, "test.sqlite"))
+	if err != nil {
+		bs.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		bs.Close()
+		ss.Close()
+	})
+	return map[string]Store{"bolt": bs, "sqlite": ss}
+}
+
+// mustUserID 创建用户并返回ID
+func mustUserID(t *testing.T, s Store, username string) int64 {
+	t.Helper()
+	if err := s.CreateUser(username, "hash", "user"); err != nil {
+		t.Fatal(err)
+	}
+	u, err := s.GetUserByUsername(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.ID
+}
+
+// seedUserData 为用户写入每种数据各一条
+func seedUserData(t *testing.T, s Store, userID int64, at time.Time) {
+	t.Helper()
+	bp := BloodPressure{UserID: userID, Systolic: 128, Diastolic: 82, HeartRate: 70, Weight: 66.5, RecordTime: at, Notes: "morning"}
+	bpID, err := s.CreateBPRecord(&bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateMeasurement(&Measurement{UserID: userID, Type: MeasureBloodGlucose, Values: map[string]float64{"value": 5.6}, Unit: "mmol/L", Context: "fasting", RecordTime: at}); err != nil {
+		t.Fatal(err)
+	}
+	med := Medication{UserID: userID, Name: "amlodipine", Dose: "5mg", Times: []string{"08:00"}}
+	medID, err := s.CreateMedication(&med)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateIntake(&MedicationIntake{UserID: userID, MedicationID: medID, Name: med.Name, Dose: med.Dose, TakenAt: at}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateAlert(&Alert{UserID: userID, RecordID: bpID, Metric: "systolic", Operator: ">=", Threshold: 120, Value: 128, TriggeredAt: at}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetUserSetting(userID, "time_windows", `[]`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// userRowCounts 统计各类数据中属于 userID 的条数
+func userRowCounts(t *testing.T, s Store, userID int64) map[string]int {
+	t.Helper()
+	counts := map[string]int{}
+	count := func(kind string, owner int64) error {
+		if owner == userID {
+			counts[kind]++
+		}
+		return nil
+	}
+	errs := []error{
+		s.ExportUsers(func(u User) error { return count("users", u.ID) }),
+		s.ExportBPRecords(func(bp BloodPressure) error { return count("bp", bp.UserID) }),
+		s.ExportMeasurements(func(m Measurement) error { return count("measurements", m.UserID) }),
+		s.ExportMedications(func(m Medication) error { return count("medications", m.UserID) }),
+		s.ExportIntakes(func(in MedicationIntake) error { return count("intakes", in.UserID) }),
+		s.ExportAlerts(func(a Alert) error { return count("alerts", a.UserID) }),
+		s.ExportUserSettings(func(id int64, key, value string) error { return count("user_settings", id) }),
+	}
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return counts
+}
+
+func TestDeleteUser(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			at := time.Date(2024, 3, 5, 7, 30, 0, 0, time.UTC)
+			alice, bob := mustUserID(t, s, "alice"), mustUserID(t, s, "bob")
+			seedUserData(t, s, alice, at)
+			seedUserData(t, s, bob, at)
+
+			if err := s.DeleteUser(alice); err != nil {
+				t.Fatal(err)
+			}
+			if got := userRowCounts(t, s, alice); len(got) != 0 {
+				t.Errorf("rows left for deleted user: %v", got)
+			}
+			want := map[string]int{"users": 1, "bp": 1, "measurements": 1, "medications": 1, "intakes": 1, "alerts": 1, "user_settings": 1}
+			got := userRowCounts(t, s, bob)
+			for kind, n := range want {
+				if got[kind] != n {
+					t.Errorf("other user's %s = %d, want %d", kind, got[kind], n)
+				}
+			}
+		})
+	}
+}
+
+func TestGetBPRecordsRange(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	newYork := time.FixedZone("EST", -5*3600)
+	// 按时间先后：01:00Z、07:30Z、07:30:00.5Z、12:00Z，分别以不同时区写入
+	times := []time.Time{
+		time.Date(2024, 3, 5, 9, 0, 0, 0, shanghai),
+		time.Date(2024, 3, 5, 2, 30, 0, 0, newYork),
+		time.Date(2024, 3, 5, 15, 30, 0, 5e8, shanghai),
+		time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC),
+	}
+
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			userID := mustUserID(t, s, "alice")
+			ids := make([]int64, len(times))
+			for i, at := range times {
+				id, err := s.CreateBPRecord(&BloodPressure{UserID: userID, Systolic: 120, Diastolic: 80, RecordTime: at})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids[i] = id
+			}
+
+			tests := []struct {
+				start, end time.Time
+				want       []int64
+			}{
+				{time.Time{}, time.Time{}, []int64{ids[3], ids[2], ids[1], ids[0]}},
+				{time.Date(2024, 3, 5, 15, 30, 0, 0, shanghai), time.Time{}, []int64{ids[3], ids[2], ids[1]}},
+				{time.Date(2024, 3, 5, 7, 30, 0, 1, time.UTC), time.Time{}, []int64{ids[3], ids[2]}},
+				{time.Time{}, time.Date(2024, 3, 5, 2, 30, 0, 0, newYork), []int64{ids[0]}},
+				{time.Date(2024, 3, 5, 1, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 20, 0, 0, 0, shanghai), []int64{ids[2], ids[1], ids[0]}},
+			}
+			for _, tt := range tests {
+				list, err := s.GetBPRecords(userID, tt.start, tt.end)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []int64
+				for _, bp := range list {
+					got = append(got, bp.ID)
+				}
+				if len(got) != len(tt.want) {
+					t.Errorf("[%s, %s) = %v, want %v", tt.start, tt.end, got, tt.want)
+					continue
+				}
+				for i := range got {
+					if got[i] != tt.want[i] {
+						t.Errorf("[%s, %s) = %v, want %v", tt.start, tt.end, got, tt.want)
+						break
+					}
+				}
+			}
+
+			list, err := s.GetBPRecords(userID, time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !list[1].RecordTime.Equal(times[2]) {
+				t.Errorf("record time = %s, want %s", list[1].RecordTime, times[2])
+			}
+		})
+	}
+}
+
+// TestSQLiteRangeUsesIndex 范围查询直接比较时间字段，可使用 (user_id, 时间) 索引
+func TestSQLiteRangeUsesIndex(t *testing.T) {
+	s := testStores(t)["sqlite"].(*sqlStore)
+	queries := map[string][2]string{
+		"idx_bp_user_time":                 {"SELECT id FROM blood_pressure WHERE user_id = ?", "record_time"},
+		"idx_measurements_user_time":       {"SELECT id FROM measurements WHERE user_id = ?", "record_time"},
+		"idx_medication_intakes_user_time": {"SELECT id FROM medication_intakes WHERE user_id = ?", "taken_at"},
+		"idx_alerts_user_time":             {"SELECT id FROM alerts WHERE user_id = ?", "triggered_at"},
+	}
+	at := time.Now()
+	for index, q := range queries {
+		query, args := s.timeRange(q[0], []interface{}{1}, q[1], at, at.Add(time.Hour))
+		rows, err := s.db.Query("EXPLAIN QUERY PLAN "+query, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var plan []string
+		for rows.Next() {
+			var id, parent, notUsed int
+			var detail string
+			if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+				t.Fatal(err)
+			}
+			plan = append(plan, detail)
+		}
+		rows.Close()
+		want := fmt.Sprintf("%s (user_id=? AND %s>? AND %s<?)", index, q[1], q[1])
+		if len(plan) != 1 || !strings.Contains(plan[0], want) {
+			t.Errorf("plan for %s = %v, want range search on %s", query, plan, index)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"health-manager/internal/config"
+)
+
+// DataCounts 各类数据的条数
+type DataCounts struct {
+	Users        int `json:"users"`
+	Records      int `json:"records"`
+	Measurements int `json:"measurements"`
+	Medications  int `json:"medications"`
+	Intakes      int `json:"intakes"`
+	Alerts       int `json:"alerts"`
+	Settings     int `json:"settings"`
+	UserSettings int `json:"user_settings"`
+}
+
+// CopyReport 切换数据库时的数据迁移报告
+type CopyReport struct {
+	Source DataCounts `json:"source"`
+	Target DataCounts `json:"target"`
+	DryRun bool       `json:"dry_run"`
+}
+
+// countData 统计存储中的数据条数
+func countData(store Store) (DataCounts, error) {
+	var counts DataCounts
+	if err := store.ExportUsers(func(User) error { counts.Users++; return nil }); err != nil {
+		return counts, err
+	}
+	if err := store.ExportBPRecords(func(BloodPressure) error { counts.Records++; return nil }); err != nil {
+		return counts, err
+	}
+	if err := store.ExportMeasurements(func(Measurement) error { counts.Measurements++; return nil }); err != nil {
+		return counts, err
+	}
+	if err := store.ExportMedications(func(Medication) error { counts.Medications++; return nil }); err != nil {
+		return counts, err
+	}
+	if err := store.ExportIntakes(func(MedicationIntake) error { counts.Intakes++; return nil }); err != nil {
+		return counts, err
+	}
+	if err := store.ExportAlerts(func(Alert) error { counts.Alerts++; return nil }); err != nil {
+		return counts, err
+	}
+	if err := store.ExportSettings(func(string, string) error { counts.Settings++; return nil }); err != nil {
+		return counts, err
+	}
+	if err := store.ExportUserSettings(func(int64, string, string) error { counts.UserSettings++; return nil }); err != nil {
+		return counts, err
+	}
+	return counts, nil
+}
+
+// copyData 将src中的用户、健康记录、测量记录、用药数据、提醒事件、全局设置和用户设置依次写入dst，保留原ID与时间
+func copyData(src, dst Store) error {
+	if err := src.ExportUsers(dst.ImportUser); err != nil {
+		return fmt.Errorf("复制用户失败: %v", err)
+	}
+	if err := src.ExportBPRecords(dst.ImportBPRecord); err != nil {
+		return fmt.Errorf("复制健康记录失败: %v", err)
+	}
+	if err := src.ExportMeasurements(dst.ImportMeasurement); err != nil {
+		return fmt.Errorf("复制测量记录失败: %v", err)
+	}
+	if err := src.ExportMedications(dst.ImportMedication); err != nil {
+		return fmt.Errorf("复制药物失败: %v", err)
+	}
+	if err := src.ExportIntakes(dst.ImportIntake); err != nil {
+		return fmt.Errorf("复制服药记录失败: %v", err)
+	}
+	if err := src.ExportAlerts(dst.ImportAlert); err != nil {
+		return fmt.Errorf("复制提醒事件失败: %v", err)
+	}
+	if err := finishImport(dst); err != nil {
+		return fmt.Errorf("同步自增序列失败: %v", err)
+	}
+	if err := src.ExportSettings(dst.SetSetting); err != nil {
+		return fmt.Errorf("复制设置失败: %v", err)
+	}
+	if err := src.ExportUserSettings(dst.SetUserSetting); err != nil {
+		return fmt.Errorf("复制用户设置失败: %v", err)
+	}
+	return nil
+}
+
+// sameDatabase 判断两个配置是否指向同一个数据库
+func sameDatabase(a, b *config.DBConfig) bool {
+	if a.Type != b.Type {
+		// 空类型等同于默认的bolt
+		return (a.Type == "" || a.Type == config.TypeBolt) && (b.Type == "" || b.Type == config.TypeBolt)
+	}
+	switch a.Type {
+	case config.TypeMySQL, config.TypePostgres:
+		return a.Host == b.Host && a.Port == b.Port && a.DBName == b.DBName
+	default:
+		return true
+	}
+}
+
+// SwitchDBWithCopy 切换数据库并将当前数据库中的数据复制到目标数据库。
+// dryRun 为 true 时只统计两边的数据条数，不复制也不切换。
+// 目标数据库必须为空，避免与已有数据发生ID冲突。
+func (m *Manager) SwitchDBWithCopy(cfg *config.DBConfig, dryRun bool) (*CopyReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.store == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if sameDatabase(m.cfg, cfg) {
+		return nil, fmt.Errorf("目标数据库与当前数据库相同，无需复制数据")
+	}
+
+	report := &CopyReport{DryRun: dryRun}
+	var err error
+	if report.Source, err = countData(m.store); err != nil {
+		return nil, fmt.Errorf("统计当前数据失败: %v", err)
+	}
+
+	target, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if report.Target, err = countData(target); err != nil {
+		target.Close()
+		return nil, fmt.Errorf("统计目标数据失败: %v", err)
+	}
+
+	if dryRun {
+		target.Close()
+		return report, nil
+	}
+
+	if t := report.Target; t.Users > 0 || t.Records > 0 || t.Measurements > 0 || t.Medications > 0 || t.Intakes > 0 || t.Alerts > 0 {
+		target.Close()
+		return report, fmt.Errorf("目标数据库已有数据（%d 个用户，%d 条记录，%d 条测量记录，%d 种药物，%d 条服药记录，%d 条提醒），请使用空数据库",
+			t.Users, t.Records, t.Measurements, t.Medications, t.Intakes, t.Alerts)
+	}
+
+	log.Printf("正在复制数据到 %s 数据库：%d 个用户，%d 条记录，%d 条测量记录，%d 项设置",
+		cfg.Type, report.Source.Users, report.Source.Records, report.Source.Measurements, report.Source.Settings)
+	if err := copyData(m.store, target); err != nil {
+		// 目标库复制前为空，清空已复制的部分数据（含设置），便于修正后重试
+		if err := target.ClearData(); err != nil {
+			log.Printf("清理目标数据库失败: %v", err)
+		}
+		target.Close()
+		return report, err
+	}
+
+	m.store.Close()
+	m.store = target
+	m.cfg = cfg
+	return report, config.SetConfig(cfg)
+}
@@ -1,20 +1,26 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"time"
 
 	"health-manager/internal/config"
 	"health-manager/internal/database"
 	"health-manager/internal/models"
+	"health-manager/internal/notify"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // GetUsers 获取所有用户
-func GetUsers(c *gin.Context) {
-	users, err := database.GetAllUsers()
+func (h *Handler) GetUsers(c *gin.Context) {
+	users, err := h.db.GetAllUsers()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
@@ -23,7 +29,7 @@ func GetUsers(c *gin.Context) {
 }
 
 // CreateUser 创建用户
-func CreateUser(c *gin.Context) {
+func (h *Handler) CreateUser(c *gin.Context) {
 	var req models.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写完整的用户信息"})
@@ -36,30 +42,34 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
-	if err := database.CreateUser(req.Username, string(hashedPwd), "user"); err != nil {
+	if err := h.db.CreateUser(req.Username, string(hashedPwd), "user"); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "用户名已存在"})
 		return
 	}
+	if u, err := h.db.GetUserByUsername(req.Username); err == nil {
+		h.notifier.Publish(notify.Event{Type: notify.EventUserCreated, UserID: u.ID,
+			Data: gin.H{"id": u.ID, "username": u.Username, "role": u.Role}})
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "用户创建成功"})
 }
 
 // DeleteUser 删除用户
-func DeleteUser(c *gin.Context) {
+func (h *Handler) DeleteUser(c *gin.Context) {
 	userID := c.Param("id")
 	var id int64
 	fmt.Sscanf(userID, "%d", &id)
 
-	role := database.GetUserRole(id)
+	role := h.db.GetUserRole(id)
 	if role == "admin" {
 		// 检查是否还有其他管理员
-		if database.CountAdmins() <= 1 {
+		if h.db.CountAdmins() <= 1 {
 			c.JSON(http.StatusForbidden, gin.H{"error": "至少保留一个管理员账号"})
 			return
 		}
 	}
 
-	if err := database.DeleteUser(id); err != nil {
+	if err := h.db.DeleteUser(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
 		return
 	}
@@ -68,12 +78,12 @@ func DeleteUser(c *gin.Context) {
 }
 
 // ToggleAdminRole 切换用户管理员角色
-func ToggleAdminRole(c *gin.Context) {
+func (h *Handler) ToggleAdminRole(c *gin.Context) {
 	userID := c.Param("id")
 	var id int64
 	fmt.Sscanf(userID, "%d", &id)
 
-	currentRole := database.GetUserRole(id)
+	currentRole := h.db.GetUserRole(id)
 	if currentRole == "" {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
@@ -82,7 +92,7 @@ func ToggleAdminRole(c *gin.Context) {
 	var newRole string
 	if currentRole == "admin" {
 		// 取消管理员权限前检查是否还有其他管理员
-		if database.CountAdmins() <= 1 {
+		if h.db.CountAdmins() <= 1 {
 			c.JSON(http.StatusForbidden, gin.H{"error": "至少保留一个管理员账号"})
 			return
 		}
@@ -91,7 +101,7 @@ func ToggleAdminRole(c *gin.Context) {
 		newRole = "admin"
 	}
 
-	if err := database.UpdateUserRole(id, newRole); err != nil {
+	if err := h.db.UpdateUserRole(id, newRole); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
 		return
 	}
@@ -104,7 +114,7 @@ func ToggleAdminRole(c *gin.Context) {
 }
 
 // ChangeUserPassword 修改用户密码
-func ChangeUserPassword(c *gin.Context) {
+func (h *Handler) ChangeUserPassword(c *gin.Context) {
 	userID := c.Param("id")
 	var id int64
 	fmt.Sscanf(userID, "%d", &id)
@@ -121,7 +131,7 @@ func ChangeUserPassword(c *gin.Context) {
 		return
 	}
 
-	if err := database.UpdateUserPassword(id, string(hashedPwd)); err != nil {
+	if err := h.db.UpdateUserPassword(id, string(hashedPwd)); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
@@ -130,7 +140,7 @@ func ChangeUserPassword(c *gin.Context) {
 }
 
 // GetDBConfig 获取数据库配置
-func GetDBConfig(c *gin.Context) {
+func (h *Handler) GetDBConfig(c *gin.Context) {
 	cfg := config.GetConfig()
 	c.JSON(http.StatusOK, gin.H{
 		"type":   cfg.Type,
@@ -142,7 +152,7 @@ func GetDBConfig(c *gin.Context) {
 }
 
 // SaveDBConfig 保存数据库配置
-func SaveDBConfig(c *gin.Context) {
+func (h *Handler) SaveDBConfig(c *gin.Context) {
 	var req models.DBConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "配置信息不完整"})
@@ -158,16 +168,32 @@ func SaveDBConfig(c *gin.Context) {
 		DBName:   req.DBName,
 	}
 
-	if err := database.SwitchDB(cfg); err != nil {
+	if req.CopyData || req.DryRun {
+		report, err := h.db.SwitchDBWithCopy(cfg, req.DryRun)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "数据迁移失败: " + err.Error(), "report": report})
+			return
+		}
+		if req.DryRun {
+			c.JSON(http.StatusOK, gin.H{"message": "预检完成", "report": report})
+			return
+		}
+		h.backups.Reload()
+		c.JSON(http.StatusOK, gin.H{"message": "数据已复制，数据库切换成功", "report": report})
+		return
+	}
+
+	if err := h.db.SwitchDB(cfg); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "数据库连接失败: " + err.Error()})
 		return
 	}
+	h.backups.Reload()
 
 	c.JSON(http.StatusOK, gin.H{"message": "数据库配置已保存并切换成功"})
 }
 
 // TestDBConfig 测试数据库连接
-func TestDBConfig(c *gin.Context) {
+func (h *Handler) TestDBConfig(c *gin.Context) {
 	var req models.DBConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "配置信息不完整"})
@@ -191,45 +217,147 @@ func TestDBConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "连接成功"})
 }
 
-// BackupDatabase 备份数据库
-func BackupDatabase(c *gin.Context) {
+// transferTimeout 备份下载与还原上传的读写时限，代替服务器对普通请求的较短超时
+const transferTimeout = 30 * time.Minute
+
+// maxRestoreSize 上传还原文件的大小上限
+const maxRestoreSize = 1 << 30
+
+// extendDeadline 延长本次请求的读写时限，避免较大的备份文件传输到一半被服务器超时中断
+func extendDeadline(c *gin.Context) {
+	rc := http.NewResponseController(c.Writer)
+	deadline := time.Now().Add(transferTimeout)
+	rc.SetReadDeadline(deadline)
+	rc.SetWriteDeadline(deadline)
+}
+
+// BackupDatabase 以附件形式下载数据库备份
+// format=logical（默认）为通用逻辑备份，format=snapshot 为bolt数据库文件快照；
+// 请求头 X-Backup-Passphrase 非空时用其加密备份，文件名追加 .enc
+func (h *Handler) BackupDatabase(c *gin.Context) {
+	format := c.DefaultQuery("format", "logical")
+	timestamp := time.Now().In(h.defaultLocation()).Format("20060102_150405")
+
+	var (
+		fileName    string
+		contentType string
+		write       func(w io.Writer) error
+	)
+	switch format {
+	case "logical":
+		fileName = "health_backup_" + timestamp + ".ndjson"
+		contentType = "application/x-ndjson"
+		write = h.db.ExportBackup
+	case "snapshot":
+		if t := h.db.Config().Type; t != "" && t != config.TypeBolt {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "文件快照仅支持本地bbolt数据库，请使用通用格式"})
+			return
+		}
+		fileName = "health_backup_" + timestamp + ".db"
+		contentType = "application/octet-stream"
+		write = h.db.Backup
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的备份格式"})
+		return
+	}
+
+	passphrase := c.GetHeader("X-Backup-Passphrase")
+	if passphrase != "" {
+		fileName += ".enc"
+		contentType = "application/octet-stream"
+		plainWrite := write
+		write = func(w io.Writer) error {
+			enc, err := database.NewEncryptWriter(w, passphrase)
+			if err != nil {
+				return err
+			}
+			if err := plainWrite(enc); err != nil {
+				return err
+			}
+			return enc.Close()
+		}
+	}
+
+	extendDeadline(c)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Status(http.StatusOK)
+	if err := write(c.Writer); err != nil {
+		// 响应头已发出，只能记录日志并中断连接
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// RestoreDatabase 还原数据库
+// 支持 multipart 上传备份文件（字段 file，不超过1GB），或以 JSON 指定自动备份目录中的文件名（name）；
+// 加密备份需同时提供 passphrase
+func (h *Handler) RestoreDatabase(c *gin.Context) {
+	extendDeadline(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRestoreSize)
+
+	file, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "备份文件不能超过1GB"})
+		return
+	}
+	if err == nil {
+		h.restoreUpload(c, file, c.PostForm("passphrase"))
+		return
+	}
+
 	var req struct {
-		Path string `json:"path"`
+		Name       string `json:"name"`
+		Passphrase string `json:"passphrase"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil || req.Path == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定备份路径"})
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传备份文件或指定自动备份文件名"})
+		return
+	}
+	path, err := h.backups.Path(req.Name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := database.BackupDB(req.Path); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "备份失败: " + err.Error()})
+	if err := h.db.RestoreDB(path, req.Passphrase); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "还原失败: " + err.Error()})
 		return
 	}
+	h.backups.Reload()
 
-	c.JSON(http.StatusOK, gin.H{"message": "备份成功", "path": req.Path})
+	c.JSON(http.StatusOK, gin.H{"message": "还原成功，请重新登录"})
 }
 
-// RestoreDatabase 还原数据库
-func RestoreDatabase(c *gin.Context) {
-	var req struct {
-		Path string `json:"path"`
+// restoreUpload 将上传的备份文件保存到临时文件后还原
+func (h *Handler) restoreUpload(c *gin.Context, file *multipart.FileHeader, passphrase string) {
+	tmp, err := os.CreateTemp("data", "restore-*.upload")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法保存上传文件"})
+		return
 	}
-	if err := c.ShouldBindJSON(&req); err != nil || req.Path == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定还原文件路径"})
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	if err := c.SaveUploadedFile(file, tmpPath); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "上传失败: " + err.Error()})
 		return
 	}
 
-	if err := database.RestoreDB(req.Path); err != nil {
+	if err := h.db.RestoreDB(tmpPath, passphrase); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "还原失败: " + err.Error()})
 		return
 	}
+	h.backups.Reload()
 
 	c.JSON(http.StatusOK, gin.H{"message": "还原成功，请重新登录"})
 }
 
 // GetIdleTimeout 获取全局自动退出时间
-func GetIdleTimeout(c *gin.Context) {
-	value, err := database.GetSetting("idle_timeout")
+func (h *Handler) GetIdleTimeout(c *gin.Context) {
+	value, err := h.db.GetSetting("idle_timeout")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取配置失败"})
 		return
@@ -241,7 +369,7 @@ func GetIdleTimeout(c *gin.Context) {
 }
 
 // SetIdleTimeout 设置全局自动退出时间
-func SetIdleTimeout(c *gin.Context) {
+func (h *Handler) SetIdleTimeout(c *gin.Context) {
 	var req struct {
 		Timeout int `json:"timeout"`
 	}
@@ -255,7 +383,7 @@ func SetIdleTimeout(c *gin.Context) {
 		return
 	}
 
-	if err := database.SetSetting("idle_timeout", fmt.Sprintf("%d", req.Timeout)); err != nil {
+	if err := h.db.SetSetting("idle_timeout", fmt.Sprintf("%d", req.Timeout)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
 		return
 	}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+
+	"health-manager/internal/database"
+	"health-manager/internal/health"
+	"health-manager/internal/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// alertRulesKey 提醒规则在用户设置中的键名
+const alertRulesKey = "alert_rules"
+
+// userAlertRules 读取用户的提醒规则，未设置或损坏时返回 nil
+func (h *Handler) userAlertRules(userID int64) []health.AlertRule {
+	value, _ := h.db.GetUserSetting(userID, alertRulesKey)
+	if value == "" {
+		return nil
+	}
+	var rules []health.AlertRule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil || health.ValidateAlertRules(rules) != nil {
+		return nil
+	}
+	return rules
+}
+
+// alertValue 取出记录中规则对应的指标数值，记录未填写该指标时 ok 为 false。
+// 体重变化与 Days 天内（不含本条）最早一次体重比较
+func (h *Handler) alertValue(rule health.AlertRule, bp database.BloodPressure) (float64, bool) {
+	switch rule.Metric {
+	case health.AlertSystolic:
+		return float64(bp.Systolic), bp.Systolic > 0
+	case health.AlertDiastolic:
+		return float64(bp.Diastolic), bp.Diastolic > 0
+	case health.AlertHeartRate:
+		return float64(bp.HeartRate), bp.HeartRate > 0
+	case health.AlertWeight:
+		return bp.Weight, bp.Weight > 0
+	case health.AlertWeightChange:
+		if bp.Weight <= 0 {
+			return 0, false
+		}
+		start := bp.RecordTime.AddDate(0, 0, -rule.ChangeDays())
+		records, err := h.db.GetBPRecords(bp.UserID, start, bp.RecordTime)
+		if err != nil {
+			return 0, false
+		}
+		// 记录按时间倒序，从最早的开始找第一条填写了体重的记录
+		for i := len(records) - 1; i >= 0; i-- {
+			if records[i].ID != bp.ID && records[i].Weight > 0 {
+				return math.Round((bp.Weight-records[i].Weight)*10) / 10, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// checkAlerts 按用户的提醒规则检查新记录，保存触发的提醒事件并发送通知。
+// 检查失败只记录日志，不影响记录保存
+func (h *Handler) checkAlerts(bp database.BloodPressure) []database.Alert {
+	alerts := []database.Alert{}
+	for _, rule := range h.userAlertRules(bp.UserID) {
+		value, ok := h.alertValue(rule, bp)
+		if !ok || !rule.Match(value) {
+			continue
+		}
+		a := database.Alert{
+			UserID:      bp.UserID,
+			RecordID:    bp.ID,
+			Metric:      rule.Metric,
+			Operator:    rule.Operator,
+			Threshold:   rule.Value,
+			Value:       value,
+			Message:     rule.Describe(value),
+			TriggeredAt: bp.RecordTime,
+		}
+		if _, err := h.db.CreateAlert(&a); err != nil {
+			log.Printf("保存提醒事件失败: %v", err)
+			continue
+		}
+		alerts = append(alerts, a)
+		h.notifier.Publish(notify.Event{Type: notify.EventAlertTriggered, UserID: bp.UserID, Data: a})
+	}
+	return alerts
+}
+
+// GetAlerts 获取提醒事件，日期范围与 /api/bp 相同；unread=1 时只返回未读事件
+func (h *Handler) GetAlerts(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+
+	start, end, err := dateRange(c.Query("start_date"), c.Query("end_date"), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.db.GetAlerts(userID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "1"
+	alerts := []database.Alert{}
+	unread := 0
+	for _, a := range list {
+		if a.ReadAt == nil {
+			unread++
+		} else if unreadOnly {
+			continue
+		}
+		a.TriggeredAt = a.TriggeredAt.In(loc)
+		alerts = append(alerts, a)
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts, "unread": unread})
+}
+
+// MarkAlertRead 将提醒事件标记为已读
+func (h *Handler) MarkAlertRead(c *gin.Context) {
+	var id int64
+	fmt.Sscanf(c.Param("id"), "%d", &id)
+
+	if err := h.db.MarkAlertRead(id, c.GetInt64("user_id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提醒不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已标记为已读"})
+}
+
+// GetAlertRules 获取当前用户的提醒规则及可选指标、比较方式
+func (h *Handler) GetAlertRules(c *gin.Context) {
+	rules := h.userAlertRules(c.GetInt64("user_id"))
+	if rules == nil {
+		rules = []health.AlertRule{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rules":     rules,
+		"metrics":   health.AlertMetrics,
+		"operators": health.AlertOperators,
+	})
+}
+
+// SetAlertRules 设置当前用户的提醒规则，为空时关闭提醒
+func (h *Handler) SetAlertRules(c *gin.Context) {
+	var req struct {
+		Rules []health.AlertRule `json:"rules"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+	if err := health.ValidateAlertRules(req.Rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	value := ""
+	if len(req.Rules) > 0 {
+		data, _ := json.Marshal(req.Rules)
+		value = string(data)
+	}
+	if err := h.db.SetUserSetting(c.GetInt64("user_id"), alertRulesKey, value); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"health-manager/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCheckAlertsWeightChange(t *testing.T) {
+	s := newTestServer(t)
+	cookies := s.login()
+	rules := `[{"metric":"weight_change","operator":">=","value":2,"days":7},{"metric":"weight","operator":"<","value":50}]`
+	if err := s.db.SetUserSetting(1, alertRulesKey, rules); err != nil {
+		t.Fatal(err)
+	}
+
+	create := func(body gin.H) []database.Alert {
+		t.Helper()
+		w := s.do(http.MethodPost, "/api/bp", body, cookies)
+		if w.Code != http.StatusOK {
+			t.Fatalf("create %v: %d %s", body, w.Code, w.Body)
+		}
+		var resp struct {
+			Alerts []database.Alert `json:"alerts"`
+		}
+		decode(t, w, &resp)
+		return resp.Alerts
+	}
+
+	tests := []struct {
+		name   string
+		body   gin.H
+		metric string
+		value  float64
+	}{
+		{"第一条体重记录", gin.H{"weight": 70, "record_time": "2024-03-01 08:00"}, "", 0},
+		{"变化未达阈值", gin.H{"weight": 71.5, "record_time": "2024-03-04 08:00"}, "", 0},
+		{"与7天内最早的体重比较", gin.H{"weight": 72.2, "record_time": "2024-03-05 08:00"}, "weight_change", 2.2},
+		{"没有体重的记录不检查", gin.H{"systolic": 120, "diastolic": 80, "record_time": "2024-03-06 08:00"}, "", 0},
+		// 03-01 已超出7天，最早的是 03-04 的 71.5
+		{"体重下降", gin.H{"weight": 69.4, "record_time": "2024-03-10 08:00"}, "weight_change", -2.1},
+		{"区间内没有其他体重", gin.H{"weight": 49, "record_time": "2024-04-01 08:00"}, "weight", 49},
+	}
+	for _, tt := range tests {
+		alerts := create(tt.body)
+		if tt.metric == "" {
+			if len(alerts) != 0 {
+				t.Errorf("%s: alerts = %+v, want none", tt.name, alerts)
+			}
+			continue
+		}
+		if len(alerts) != 1 || alerts[0].Metric != tt.metric || alerts[0].Value != tt.value {
+			t.Errorf("%s: alerts = %+v, want %s %v", tt.name, alerts, tt.metric, tt.value)
+		}
+	}
+}
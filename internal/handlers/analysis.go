@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"health-manager/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bpWindowsKey 测量时段在用户设置中的键名
+const bpWindowsKey = "bp_time_windows"
+
+// analysisDays 未指定日期范围时分析最近的天数（家庭血压监测建议连续测量7天）
+const analysisDays = 7
+
+// loadTimeWindows 读取保存为JSON的时段设置，未设置或损坏时返回 nil
+func loadTimeWindows(value string) []health.TimeWindow {
+	if value == "" {
+		return nil
+	}
+	var windows []health.TimeWindow
+	if err := json.Unmarshal([]byte(value), &windows); err != nil || health.ValidateTimeWindows(windows) != nil {
+		return nil
+	}
+	return windows
+}
+
+// userTimeWindows 用户的测量时段，未设置时使用默认时段
+func (h *Handler) userTimeWindows(userID int64) []health.TimeWindow {
+	value, _ := h.db.GetUserSetting(userID, bpWindowsKey)
+	if windows := loadTimeWindows(value); windows != nil {
+		return windows
+	}
+	return health.DefaultTimeWindows()
+}
+
+// GetBPWindows 获取当前用户的测量时段及默认时段
+func (h *Handler) GetBPWindows(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	value, _ := h.db.GetUserSetting(userID, bpWindowsKey)
+	c.JSON(http.StatusOK, gin.H{
+		"windows":         h.userTimeWindows(userID),
+		"custom":          loadTimeWindows(value) != nil,
+		"default_windows": health.DefaultTimeWindows(),
+	})
+}
+
+// SetBPWindows 设置当前用户的测量时段，为空时恢复默认时段
+func (h *Handler) SetBPWindows(c *gin.Context) {
+	var req struct {
+		Windows []health.TimeWindow `json:"windows"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+
+	value := ""
+	if len(req.Windows) > 0 {
+		if err := health.ValidateTimeWindows(req.Windows); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		data, _ := json.Marshal(req.Windows)
+		value = string(data)
+	}
+
+	if err := h.db.SetUserSetting(c.GetInt64("user_id"), bpWindowsKey, value); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
+
+// windowStats 一个时段内的血压统计
+type windowStats struct {
+	health.TimeWindow
+	readingSummary
+}
+
+// dayAnalysis 一天的早晨、晚间读数及晨峰
+type dayAnalysis struct {
+	Date    string          `json:"date"`
+	Morning *readingSummary `json:"morning"`
+	Evening *readingSummary `json:"evening"`
+	// Surge 当天早晨收缩压均值减去前一天晚间收缩压均值，缺少任一时为空
+	Surge *float64 `json:"surge"`
+}
+
+// homeProtocol 家庭血压监测：去掉第1天后早晨、晚间全部读数的平均值
+type homeProtocol struct {
+	DiscardedDate string `json:"discarded_date,omitempty"`
+	Days          int    `json:"days"`
+	readingSummary
+	// Sufficient 去掉第1天后的天数是否满足要求
+	Sufficient bool `json:"sufficient"`
+	// Hypertensive 平均值是否达到家庭血压诊断阈值
+	Hypertensive bool `json:"hypertensive"`
+}
+
+// meanDifference 两组读数均值之差（a 减 b，保留1位小数），任一组没有读数时为空
+func meanDifference(a, b *health.Summary) *float64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	d := math.Round((a.Mean-b.Mean)*10) / 10
+	return &d
+}
+
+// GetBPAnalysis 按测量时段分析血压：各时段统计、早晨与晚间均值及差值、晨峰和家庭血压平均值。
+// 读数按用户时区的记录时间归入时段；未指定日期范围时分析最近7天
+func (h *Handler) GetBPAnalysis(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+
+	startDate, endDate := c.Query("start_date"), c.Query("end_date")
+	if startDate == "" && endDate == "" {
+		today := time.Now().In(loc)
+		endDate = today.Format("2006-01-02")
+		startDate = today.AddDate(0, 0, 1-analysisDays).Format("2006-01-02")
+	}
+	start, end, err := dateRange(startDate, endDate, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	records, err := h.db.GetBPRecords(userID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	windows := h.userTimeWindows(userID)
+	byWindow := map[string]*readings{}
+	for _, w := range windows {
+		byWindow[w.Name] = &readings{}
+	}
+	var other readings
+	// 早晨、晚间读数按所属日期归类，跨午夜时段的读数归入开始的那一天
+	mornings := map[string]*readings{}
+	evenings := map[string]*readings{}
+
+	for _, bp := range records {
+		if bp.Systolic <= 0 && bp.Diastolic <= 0 {
+			continue
+		}
+		t := bp.RecordTime.In(loc)
+		w, ok := health.MatchWindow(windows, t)
+		if !ok {
+			other.add(bp)
+			continue
+		}
+		byWindow[w.Name].add(bp)
+
+		var days map[string]*readings
+		switch w.Name {
+		case health.WindowMorning:
+			days = mornings
+		case health.WindowEvening:
+			days = evenings
+		default:
+			continue
+		}
+		date := w.Date(t)
+		if days[date] == nil {
+			days[date] = &readings{}
+		}
+		days[date].add(bp)
+	}
+
+	windowResult := make([]windowStats, 0, len(windows))
+	for _, w := range windows {
+		windowResult = append(windowResult, windowStats{TimeWindow: w, readingSummary: byWindow[w.Name].summary()})
+	}
+
+	var dates []string
+	for date := range mornings {
+		dates = append(dates, date)
+	}
+	for date := range evenings {
+		if mornings[date] == nil {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+
+	days := make([]dayAnalysis, 0, len(dates))
+	var surges []float64
+	var home readings
+	for i, date := range dates {
+		day := dayAnalysis{Date: date}
+		if r := mornings[date]; r != nil {
+			s := r.summary()
+			day.Morning = &s
+			if i > 0 {
+				home.merge(r)
+			}
+		}
+		if r := evenings[date]; r != nil {
+			s := r.summary()
+			day.Evening = &s
+			if i > 0 {
+				home.merge(r)
+			}
+		}
+		t, _ := time.ParseInLocation("2006-01-02", date, loc)
+		if prev := evenings[t.AddDate(0, 0, -1).Format("2006-01-02")]; prev != nil && day.Morning != nil {
+			if surge := meanDifference(day.Morning.Systolic, prev.summary().Systolic); surge != nil {
+				day.Surge = surge
+				surges = append(surges, *surge)
+			}
+		}
+		days = append(days, day)
+	}
+
+	// 早晨与晚间均值之差（早晨减晚间）
+	var difference gin.H
+	morning, evening := byWindow[health.WindowMorning].summary(), byWindow[health.WindowEvening].summary()
+	if morning.Count > 0 && evening.Count > 0 {
+		difference = gin.H{
+			"systolic":  meanDifference(morning.Systolic, evening.Systolic),
+			"diastolic": meanDifference(morning.Diastolic, evening.Diastolic),
+		}
+	}
+
+	protocol := homeProtocol{readingSummary: home.summary()}
+	if len(dates) > 0 {
+		protocol.DiscardedDate = dates[0]
+		protocol.Days = len(dates) - 1
+	}
+	protocol.Sufficient = protocol.Days >= health.HomeMinDays
+	if s := protocol.readingSummary; s.Count > 0 {
+		protocol.Hypertensive = (s.Systolic != nil && s.Systolic.Mean >= health.HomeSystolicThreshold) ||
+			(s.Diastolic != nil && s.Diastolic.Mean >= health.HomeDiastolicThreshold)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date":    startDate,
+		"end_date":      endDate,
+		"windows":       windowResult,
+		"other":         other.summary(),
+		"morning":       morning,
+		"evening":       evening,
+		"difference":    difference,
+		"days":          days,
+		"morning_surge": health.Summarize(surges),
+		"home_protocol": protocol,
+	})
+}
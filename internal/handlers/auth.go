@@ -4,7 +4,6 @@ import (
 	"net/http"
 	"time"
 
-	"health-manager/internal/database"
 	"health-manager/internal/models"
 
 	"github.com/gin-contrib/sessions"
@@ -13,14 +12,14 @@ import (
 )
 
 // Login 用户登录
-func Login(c *gin.Context) {
+func (h *Handler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入用户名和密码"})
 		return
 	}
 
-	user, err := database.GetUserByUsername(req.Username)
+	user, err := h.db.GetUserByUsername(req.Username)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
 		return
@@ -50,7 +49,7 @@ func Login(c *gin.Context) {
 }
 
 // Logout 用户登出
-func Logout(c *gin.Context) {
+func (h *Handler) Logout(c *gin.Context) {
 	session := sessions.Default(c)
 	session.Clear()
 	session.Save()
@@ -58,7 +57,7 @@ func Logout(c *gin.Context) {
 }
 
 // GetCurrentUser 获取当前用户信息
-func GetCurrentUser(c *gin.Context) {
+func (h *Handler) GetCurrentUser(c *gin.Context) {
 	session := sessions.Default(c)
 	userID := session.Get("user_id")
 	if userID == nil {
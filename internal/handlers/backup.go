@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"health-manager/internal/backup"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBackups 获取自动备份配置、运行状态和备份文件列表
+func (h *Handler) GetBackups(c *gin.Context) {
+	cfg, err := h.backups.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取自动备份配置失败"})
+		return
+	}
+	files, err := h.backups.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取备份目录失败"})
+		return
+	}
+	if files == nil {
+		files = []backup.File{}
+	}
+	// 不向前端返回备份密码
+	cfg.Passphrase = ""
+
+	c.JSON(http.StatusOK, gin.H{
+		"config": cfg,
+		"status": h.backups.Status(),
+		"files":  files,
+	})
+}
+
+// SaveBackupConfig 保存自动备份配置
+func (h *Handler) SaveBackupConfig(c *gin.Context) {
+	var cfg backup.Config
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := h.backups.SaveConfig(cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "自动备份设置已保存"})
+}
+
+// RunBackup 立即执行一次备份
+func (h *Handler) RunBackup(c *gin.Context) {
+	file, err := h.backups.RunNow()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "备份失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "备份成功", "file": file})
+}
+
+// DownloadBackup 下载备份目录中的备份文件
+func (h *Handler) DownloadBackup(c *gin.Context) {
+	path, err := h.backups.Path(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	extendDeadline(c)
+	c.FileAttachment(path, c.Param("name"))
+}
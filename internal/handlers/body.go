@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"health-manager/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bmiStandardKey BMI 分类标准在全局设置和用户设置中的键名
+const bmiStandardKey = "bmi_standard"
+
+// defaultBMIStandard 获取全局 BMI 分类标准
+func (h *Handler) defaultBMIStandard() string {
+	if id, _ := h.db.GetSetting(bmiStandardKey); health.ValidBMIStandard(id) {
+		return id
+	}
+	return health.DefaultBMIStandard
+}
+
+// userBMIStandard 获取用户使用的 BMI 分类标准，用户未设置时使用全局标准
+func (h *Handler) userBMIStandard(userID int64) string {
+	if id, _ := h.db.GetUserSetting(userID, bmiStandardKey); health.ValidBMIStandard(id) {
+		return id
+	}
+	return h.defaultBMIStandard()
+}
+
+// GetBMIStandard 获取当前用户的 BMI 分类标准、全局标准及可选标准
+func (h *Handler) GetBMIStandard(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	user, _ := h.db.GetUserSetting(userID, bmiStandardKey)
+	c.JSON(http.StatusOK, gin.H{
+		"standard":         h.userBMIStandard(userID),
+		"user_standard":    user,
+		"default_standard": h.defaultBMIStandard(),
+		"standards":        health.BMIStandards,
+	})
+}
+
+// SetBMIStandard 设置当前用户的 BMI 分类标准，为空时恢复使用全局标准
+func (h *Handler) SetBMIStandard(c *gin.Context) {
+	var req struct {
+		Standard string `json:"standard"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+	if req.Standard != "" && !health.ValidBMIStandard(req.Standard) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的BMI标准"})
+		return
+	}
+
+	if err := h.db.SetUserSetting(c.GetInt64("user_id"), bmiStandardKey, req.Standard); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
+
+// SetDefaultBMIStandard 设置全局 BMI 分类标准
+func (h *Handler) SetDefaultBMIStandard(c *gin.Context) {
+	var req struct {
+		Standard string `json:"standard"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !health.ValidBMIStandard(req.Standard) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的BMI标准"})
+		return
+	}
+
+	if err := h.db.SetSetting(bmiStandardKey, req.Standard); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"net/mail"
+
+	"health-manager/internal/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// smtpView 返回给前端的邮件服务器配置，不包含密码
+type smtpView struct {
+	notify.SMTPConfig
+	HasPassword bool `json:"has_password"`
+}
+
+// GetSMTPConfig 获取邮件服务器配置
+func (h *Handler) GetSMTPConfig(c *gin.Context) {
+	cfg, err := h.mailer.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取邮件配置失败"})
+		return
+	}
+	v := smtpView{SMTPConfig: cfg, HasPassword: cfg.Password != ""}
+	v.Password = ""
+	c.JSON(http.StatusOK, v)
+}
+
+// SaveSMTPConfig 保存邮件服务器配置，未填写密码时沿用原密码
+func (h *Handler) SaveSMTPConfig(c *gin.Context) {
+	var cfg notify.SMTPConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if err := h.mailer.SaveConfig(cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "邮件设置已保存"})
+}
+
+// TestSMTP 按提交的配置发送一封测试邮件，配置无需先保存
+func (h *Handler) TestSMTP(c *gin.Context) {
+	var req struct {
+		notify.SMTPConfig
+		To string `json:"to"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if _, err := mail.ParseAddress(req.To); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "收件人地址无效"})
+		return
+	}
+	if err := h.mailer.Test(req.SMTPConfig, req.To); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "发送失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "测试邮件已发送"})
+}
+
+// GetEmailSettings 获取当前用户的邮箱和邮件语言，以及服务器是否启用了邮件通知
+func (h *Handler) GetEmailSettings(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	lang, _ := h.db.GetUserSetting(userID, notify.EmailLanguageKey)
+	cfg, _ := h.mailer.LoadConfig()
+	c.JSON(http.StatusOK, gin.H{
+		"email":            h.db.GetUserEmail(userID),
+		"language":         lang,
+		"default_language": cfg.Language,
+		"enabled":          cfg.Enabled,
+	})
+}
+
+// SetEmailSettings 设置当前用户的邮箱和邮件语言，邮箱为空时不接收邮件，语言为空时使用默认语言
+func (h *Handler) SetEmailSettings(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email"`
+		Language string `json:"language"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+	if req.Email != "" {
+		addr, err := mail.ParseAddress(req.Email)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "邮箱地址无效"})
+			return
+		}
+		req.Email = addr.Address
+	}
+	if req.Language != "" && !notify.ValidLanguage(req.Language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邮件语言必须为 zh 或 en"})
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	if err := h.db.UpdateUserEmail(userID, req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	if err := h.db.SetUserSetting(userID, notify.EmailLanguageKey, req.Language); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"health-manager/internal/database"
+	"health-manager/internal/health"
+	"health-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// glucoseTargetsKey 血糖控制目标在全局设置和用户设置中的键名
+const glucoseTargetsKey = "glucose_targets"
+
+// glucoseReading 血糖记录，同时给出 mmol/L 与 mg/dL 数值及判定结果
+type glucoseReading struct {
+	ID          int64                `json:"id"`
+	Value       float64              `json:"value"` // mmol/L
+	ValueMgdL   float64              `json:"value_mgdl"`
+	Context     string               `json:"context"`
+	ContextName string               `json:"context_name"`
+	Status      health.GlucoseStatus `json:"status"`
+	RecordTime  time.Time            `json:"record_time"`
+	Notes       string               `json:"notes"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   *time.Time           `json:"updated_at,omitempty"`
+}
+
+// newGlucoseReading 将通用测量记录转换为血糖记录
+func newGlucoseReading(m database.Measurement, targets health.GlucoseTargets, loc *time.Location) glucoseReading {
+	value := m.Values["value"]
+	return glucoseReading{
+		ID:          m.ID,
+		Value:       value,
+		ValueMgdL:   health.ToMgdL(value),
+		Context:     m.Context,
+		ContextName: health.GlucoseContextName(m.Context),
+		Status:      health.ClassifyGlucose(value, m.Context, targets),
+		RecordTime:  m.RecordTime.In(loc),
+		Notes:       m.Notes,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// loadGlucoseTargets 读取保存为JSON的控制目标，未设置或损坏时返回 nil
+func loadGlucoseTargets(value string) health.GlucoseTargets {
+	if value == "" {
+		return nil
+	}
+	var targets health.GlucoseTargets
+	if err := json.Unmarshal([]byte(value), &targets); err != nil || targets.Validate() != nil {
+		return nil
+	}
+	return targets
+}
+
+// globalGlucoseTargets 默认控制目标叠加管理员设置的全局目标
+func (h *Handler) globalGlucoseTargets() health.GlucoseTargets {
+	value, _ := h.db.GetSetting(glucoseTargetsKey)
+	return health.DefaultGlucoseTargets().Merge(loadGlucoseTargets(value))
+}
+
+// userGlucoseTargets 用户生效的控制目标：全局目标叠加用户自己的设置
+func (h *Handler) userGlucoseTargets(userID int64) health.GlucoseTargets {
+	value, _ := h.db.GetUserSetting(userID, glucoseTargetsKey)
+	return h.globalGlucoseTargets().Merge(loadGlucoseTargets(value))
+}
+
+// normalizeGlucose 将血糖数值换算为 mmol/L 并校验测量场景
+func normalizeGlucose(values map[string]float64, unit, context string) error {
+	mmol, err := health.ToMmol(values["value"], unit)
+	if err != nil {
+		return err
+	}
+	values["value"] = mmol
+	if context != "" && health.GlucoseContextName(context) == "" {
+		return fmt.Errorf("不支持的测量场景")
+	}
+	return nil
+}
+
+// GetGlucose 获取血糖记录，日期范围与 /api/bp 相同，可按测量场景筛选
+func (h *Handler) GetGlucose(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+
+	start, end, err := dateRange(c.Query("start_date"), c.Query("end_date"), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.db.GetMeasurements(userID, database.MeasureBloodGlucose, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	targets := h.userGlucoseTargets(userID)
+	context := c.Query("context")
+	readings := []glucoseReading{}
+	for _, m := range list {
+		if context == "" || m.Context == context {
+			readings = append(readings, newGlucoseReading(m, targets, loc))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"readings": readings, "targets": targets})
+}
+
+// CreateGlucose 记录血糖，支持 mmol/L 与 mg/dL 输入，统一按 mmol/L 保存
+func (h *Handler) CreateGlucose(c *gin.Context) {
+	var req models.CreateGlucoseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "数据格式错误"})
+		return
+	}
+	if req.Value <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写血糖数值"})
+		return
+	}
+
+	values := map[string]float64{"value": req.Value}
+	if err := normalizeGlucose(values, req.Unit, req.Context); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+	recordTime := time.Now().In(loc)
+	if req.RecordTime != "" {
+		t, err := parseRecordTime(req.RecordTime, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		recordTime = t
+	}
+
+	m := database.Measurement{
+		UserID:     userID,
+		Type:       database.MeasureBloodGlucose,
+		Values:     values,
+		Unit:       health.UnitMmolL,
+		Context:    req.Context,
+		RecordTime: recordTime,
+		Notes:      req.Notes,
+	}
+	if _, err := h.db.CreateMeasurement(&m); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+	h.measurementCreated(m, loc)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "记录成功",
+		"reading": newGlucoseReading(m, h.userGlucoseTargets(userID), loc),
+	})
+}
+
+// getGlucoseRecord 获取用户的单条血糖记录
+func (h *Handler) getGlucoseRecord(c *gin.Context) (*database.Measurement, bool) {
+	var id int64
+	fmt.Sscanf(c.Param("id"), "%d", &id)
+
+	m, err := h.db.GetMeasurement(id, c.GetInt64("user_id"))
+	if err != nil || m.Type != database.MeasureBloodGlucose {
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		return nil, false
+	}
+	return m, true
+}
+
+// UpdateGlucose 修改血糖记录，只更新请求中提供的字段
+func (h *Handler) UpdateGlucose(c *gin.Context) {
+	var req models.UpdateGlucoseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "数据格式错误"})
+		return
+	}
+
+	m, ok := h.getGlucoseRecord(c)
+	if !ok {
+		return
+	}
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+
+	if req.Value != nil {
+		if *req.Value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请填写血糖数值"})
+			return
+		}
+		m.Values = map[string]float64{"value": *req.Value}
+	}
+	if req.Context != nil {
+		m.Context = *req.Context
+	}
+	if req.Notes != nil {
+		m.Notes = *req.Notes
+	}
+	if req.RecordTime != nil {
+		t, err := parseRecordTime(*req.RecordTime, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		m.RecordTime = t
+	}
+
+	// 单位只作用于本次提交的数值
+	unit := req.Unit
+	if req.Value == nil {
+		unit = health.UnitMmolL
+	}
+	if err := normalizeGlucose(m.Values, unit, m.Context); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.UpdateMeasurement(m); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "修改成功",
+		"reading": newGlucoseReading(*m, h.userGlucoseTargets(userID), loc),
+	})
+}
+
+// DeleteGlucose 删除血糖记录
+func (h *Handler) DeleteGlucose(c *gin.Context) {
+	m, ok := h.getGlucoseRecord(c)
+	if !ok {
+		return
+	}
+	if err := h.db.DeleteMeasurement(m.ID, m.UserID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		return
+	}
+	h.measurementDeleted(*m)
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetGlucoseTargets 获取当前用户生效的血糖控制目标、用户自己的设置和全局目标
+func (h *Handler) GetGlucoseTargets(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	value, _ := h.db.GetUserSetting(userID, glucoseTargetsKey)
+	user := loadGlucoseTargets(value)
+	if user == nil {
+		user = health.GlucoseTargets{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"targets":  h.userGlucoseTargets(userID),
+		"user":     user,
+		"global":   h.globalGlucoseTargets(),
+		"contexts": health.GlucoseContexts,
+	})
+}
+
+// bindGlucoseTargets 解析并校验请求中的控制目标，为空时返回空字符串表示恢复默认
+func bindGlucoseTargets(c *gin.Context) (string, bool) {
+	var req struct {
+		Targets health.GlucoseTargets `json:"targets"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return "", false
+	}
+	if err := req.Targets.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	if len(req.Targets) == 0 {
+		return "", true
+	}
+	data, _ := json.Marshal(req.Targets)
+	return string(data), true
+}
+
+// SetGlucoseTargets 设置当前用户的血糖控制目标，未设置的场景沿用全局目标
+func (h *Handler) SetGlucoseTargets(c *gin.Context) {
+	value, ok := bindGlucoseTargets(c)
+	if !ok {
+		return
+	}
+	if err := h.db.SetUserSetting(c.GetInt64("user_id"), glucoseTargetsKey, value); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
+
+// SetGlobalGlucoseTargets 设置全局血糖控制目标
+func (h *Handler) SetGlobalGlucoseTargets(c *gin.Context) {
+	value, ok := bindGlucoseTargets(c)
+	if !ok {
+		return
+	}
+	if err := h.db.SetSetting(glucoseTargetsKey, value); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"health-manager/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bpGuidelineKey 血压分级指南在全局设置和用户设置中的键名
+const bpGuidelineKey = "bp_guideline"
+
+// defaultGuideline 获取全局血压分级指南
+func (h *Handler) defaultGuideline() string {
+	if id, _ := h.db.GetSetting(bpGuidelineKey); health.ValidGuideline(id) {
+		return id
+	}
+	return health.DefaultGuideline
+}
+
+// userGuideline 获取用户使用的血压分级指南，用户未设置时使用全局指南
+func (h *Handler) userGuideline(userID int64) string {
+	if id, _ := h.db.GetUserSetting(userID, bpGuidelineKey); health.ValidGuideline(id) {
+		return id
+	}
+	return h.defaultGuideline()
+}
+
+// GetBPGuideline 获取当前用户的血压分级指南、全局指南及可选指南
+func (h *Handler) GetBPGuideline(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	user, _ := h.db.GetUserSetting(userID, bpGuidelineKey)
+	c.JSON(http.StatusOK, gin.H{
+		"guideline":         h.userGuideline(userID),
+		"user_guideline":    user,
+		"default_guideline": h.defaultGuideline(),
+		"guidelines":        health.Guidelines,
+	})
+}
+
+// SetBPGuideline 设置当前用户的血压分级指南，为空时恢复使用全局指南
+func (h *Handler) SetBPGuideline(c *gin.Context) {
+	var req struct {
+		Guideline string `json:"guideline"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+	if req.Guideline != "" && !health.ValidGuideline(req.Guideline) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的分级指南"})
+		return
+	}
+
+	if err := h.db.SetUserSetting(c.GetInt64("user_id"), bpGuidelineKey, req.Guideline); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
+
+// SetDefaultBPGuideline 设置全局血压分级指南
+func (h *Handler) SetDefaultBPGuideline(c *gin.Context) {
+	var req struct {
+		Guideline string `json:"guideline"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !health.ValidGuideline(req.Guideline) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的分级指南"})
+		return
+	}
+
+	if err := h.db.SetSetting(bpGuidelineKey, req.Guideline); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"health-manager/internal/backup"
+	"health-manager/internal/database"
+	"health-manager/internal/notify"
+)
+
+// Handler 聚合所有API处理函数，通过构造函数注入数据库、自动备份调度器和通知分发
+type Handler struct {
+	db       *database.Manager
+	backups  *backup.Scheduler
+	notifier *notify.Hub
+	webhooks *notify.Webhooks
+	mailer   *notify.Mailer
+}
+
+// New 创建Handler
+func New(db *database.Manager, backups *backup.Scheduler, notifier *notify.Hub, webhooks *notify.Webhooks, mailer *notify.Mailer) *Handler {
+	return &Handler{db: db, backups: backups, notifier: notifier, webhooks: webhooks, mailer: mailer}
+}
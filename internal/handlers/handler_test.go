@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"health-manager/internal/config"
+	"health-manager/internal/database"
+	"health-manager/internal/middleware"
+	"health-manager/internal/notify"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+// recorder 记录发布的事件的通知渠道
+type recorder struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (r *recorder) Name() string { return "test" }
+
+func (r *recorder) Send(e notify.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []string
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// testServer 使用内存存储的测试服务，会话与登录校验与 main.go 相同
+type testServer struct {
+	t      *testing.T
+	store  *fakeStore
+	db     *database.Manager
+	hub    *notify.Hub
+	events *recorder
+	router *gin.Engine
+}
+
+func newTestServer(t *testing.T) *testServer {
+	gin.SetMode(gin.TestMode)
+	store := newFakeStore()
+	db := database.NewManager(store, &config.DBConfig{Type: config.TypeBolt})
+
+	events := &recorder{}
+	hub := notify.NewHub()
+	hub.Register(events)
+	h := New(db, nil, hub, nil, nil)
+
+	r := gin.New()
+	r.Use(sessions.Sessions("session", cookie.NewStore([]byte("test-secret"))))
+	r.POST("/api/login", h.Login)
+	api := r.Group("/api")
+	api.Use(middleware.AuthRequired(db))
+	api.GET("/bp", h.GetBPRecords)
+	api.POST("/bp", h.CreateBP)
+	api.GET("/bp/stats", h.GetBPStats)
+
+	return &testServer{t: t, store: store, db: db, hub: hub, events: events, router: r}
+}
+
+// do 发送请求，body 不为 nil 时编码为JSON
+func (s *testServer) do(method, path string, body interface{}, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// login 以默认管理员登录，返回会话 Cookie
+func (s *testServer) login() []*http.Cookie {
+	s.t.Helper()
+	w := s.do(http.MethodPost, "/api/login", gin.H{"username": "admin", "password": "admin123"}, nil)
+	if w.Code != http.StatusOK {
+		s.t.Fatalf("login: %d %s", w.Code, w.Body)
+	}
+	return w.Result().Cookies()
+}
+
+// decode 解析JSON响应
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid response %s: %v", w.Body, err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name string
+		body interface{}
+		code int
+	}{
+		{"缺少参数", nil, http.StatusBadRequest},
+		{"用户不存在", gin.H{"username": "nobody", "password": "admin123"}, http.StatusUnauthorized},
+		{"密码错误", gin.H{"username": "admin", "password": "wrong"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if w := s.do(http.MethodPost, "/api/login", tt.body, nil); w.Code != tt.code {
+			t.Errorf("%s: code = %d, want %d (%s)", tt.name, w.Code, tt.code, w.Body)
+		}
+	}
+
+	w := s.do(http.MethodPost, "/api/login", gin.H{"username": "admin", "password": "admin123"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code = %d, want 200 (%s)", w.Code, w.Body)
+	}
+	var resp struct {
+		User struct {
+			Username string `json:"username"`
+			Role     string `json:"role"`
+		} `json:"user"`
+		Redirect string `json:"redirect"`
+	}
+	decode(t, w, &resp)
+	if resp.User.Username != "admin" || resp.User.Role != "admin" || resp.Redirect == "" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("login did not set a session cookie")
+	}
+	if w := s.do(http.MethodGet, "/api/bp", nil, cookies); w.Code != http.StatusOK {
+		t.Errorf("with session: code = %d, want 200", w.Code)
+	}
+	if w := s.do(http.MethodGet, "/api/bp", nil, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("without session: code = %d, want 401", w.Code)
+	}
+}
+
+func TestCreateBP(t *testing.T) {
+	s := newTestServer(t)
+	cookies := s.login()
+	rules := `[{"metric":"systolic","operator":">=","value":160}]`
+	if err := s.db.SetUserSetting(1, alertRulesKey, rules); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		body gin.H
+	}{
+		{"没有血压和身高体重", gin.H{"heart_rate": 70}},
+		{"测量时间无效", gin.H{"systolic": 120, "diastolic": 80, "record_time": "yesterday"}},
+		{"测量时间晚于当前", gin.H{"systolic": 120, "diastolic": 80, "record_time": time.Now().Add(time.Hour).Format(time.RFC3339)}},
+	}
+	for _, tt := range tests {
+		if w := s.do(http.MethodPost, "/api/bp", tt.body, cookies); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: code = %d, want 400 (%s)", tt.name, w.Code, w.Body)
+		}
+	}
+
+	w := s.do(http.MethodPost, "/api/bp", gin.H{"systolic": 172, "diastolic": 95, "heart_rate": 80, "notes": "晨起"}, cookies)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code = %d, want 200 (%s)", w.Code, w.Body)
+	}
+	var resp struct {
+		ID     int64 `json:"id"`
+		Status struct {
+			Level string `json:"level"`
+		} `json:"status"`
+		Alerts []database.Alert `json:"alerts"`
+	}
+	decode(t, w, &resp)
+	if resp.ID == 0 || resp.Status.Level == "" {
+		t.Errorf("response = %s", w.Body)
+	}
+	if len(resp.Alerts) != 1 || resp.Alerts[0].RecordID != resp.ID || resp.Alerts[0].Value != 172 {
+		t.Errorf("alerts = %+v, want one systolic alert for record %d", resp.Alerts, resp.ID)
+	}
+
+	bp, err := s.db.GetBPRecord(resp.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp.Systolic != 172 || bp.Diastolic != 95 || bp.HeartRate != 80 || bp.Notes != "晨起" || bp.CreatedAt.IsZero() {
+		t.Errorf("saved record = %+v", bp)
+	}
+
+	if len(s.store.alerts) != 1 || s.store.alerts[0].RecordID != resp.ID {
+		t.Errorf("stored alerts = %+v", s.store.alerts)
+	}
+
+	// 事件异步分发，顺序不固定
+	s.hub.Wait()
+	types := s.events.types()
+	sort.Strings(types)
+	if len(types) != 2 || types[0] != notify.EventAlertTriggered || types[1] != notify.EventRecordCreated {
+		t.Errorf("events = %v, want %s and %s", types, notify.EventRecordCreated, notify.EventAlertTriggered)
+	}
+}
+
+func TestGetBPRecords(t *testing.T) {
+	s := newTestServer(t)
+	cookies := s.login()
+	if err := s.db.UpdateUserTimeZone(1, "Asia/Shanghai"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, body := range []gin.H{
+		{"systolic": 118, "diastolic": 76, "record_time": "2024-03-04 23:30"},
+		{"systolic": 135, "diastolic": 88, "record_time": "2024-03-05 07:30"},
+		{"systolic": 142, "diastolic": 91, "record_time": "2024-03-05 21:00"},
+		{"height": 170, "weight": 65, "record_time": "2024-03-06 08:00"},
+	} {
+		if w := s.do(http.MethodPost, "/api/bp", body, cookies); w.Code != http.StatusOK {
+			t.Fatalf("create %v: %d %s", body, w.Code, w.Body)
+		}
+	}
+
+	get := func(query string) []bpRecord {
+		t.Helper()
+		w := s.do(http.MethodGet, "/api/bp"+query, nil, cookies)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", query, w.Code, w.Body)
+		}
+		var resp struct {
+			Records []bpRecord `json:"records"`
+		}
+		decode(t, w, &resp)
+		return resp.Records
+	}
+
+	all := get("")
+	if len(all) != 4 {
+		t.Fatalf("got %d records, want 4", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].RecordTime.After(all[i-1].RecordTime) {
+			t.Errorf("records not in descending order: %v after %v", all[i].RecordTime, all[i-1].RecordTime)
+		}
+	}
+	if all[0].Body == nil || all[0].Body.BMI == 0 {
+		t.Errorf("body metrics missing for height/weight record: %+v", all[0])
+	}
+
+	// 日期按用户时区的自然日计算
+	day := get("?start_date=2024-03-05&end_date=2024-03-05")
+	if len(day) != 2 || day[0].Systolic != 142 || day[1].Systolic != 135 {
+		t.Fatalf("records on 2024-03-05 = %+v", day)
+	}
+	if _, offset := day[1].RecordTime.Zone(); offset != 8*3600 || day[1].RecordTime.Format("15:04") != "07:30" {
+		t.Errorf("record_time = %v, want 07:30 +08:00", day[1].RecordTime)
+	}
+	if day[0].Status == nil || day[0].Status.Level == "" {
+		t.Errorf("status missing: %+v", day[0])
+	}
+
+	if w := s.do(http.MethodGet, "/api/bp?start_date=2024-13-01", nil, cookies); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid date: code = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"health-manager/internal/database"
+	"health-manager/internal/health"
+	"health-manager/internal/models"
+	"health-manager/internal/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMeasurementTypes 获取支持的测量类型
+func (h *Handler) GetMeasurementTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"types": database.MeasurementTypes})
+}
+
+// GetMeasurements 获取测量记录，包含由健康记录映射出的血压、心率、身高、体重、腰围
+func (h *Handler) GetMeasurements(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+
+	typ := c.Query("type")
+	if typ != "" {
+		if _, ok := database.LookupMeasurementType(typ); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的测量类型"})
+			return
+		}
+	}
+
+	start, end, err := dateRange(c.Query("start_date"), c.Query("end_date"), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.db.GetMeasurements(userID, typ, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	if typ == "" || database.MappedFromBP(typ) {
+		records, err := h.db.GetBPRecords(userID, start, end)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+			return
+		}
+		for _, bp := range records {
+			for _, m := range database.MeasurementsFromBP(bp) {
+				if typ == "" || m.Type == typ {
+					list = append(list, m)
+				}
+			}
+		}
+		database.SortMeasurements(list)
+	}
+
+	for i := range list {
+		list[i].RecordTime = list[i].RecordTime.In(loc)
+	}
+	c.JSON(http.StatusOK, gin.H{"measurements": list})
+}
+
+// validateValues 校验数值字段是否属于该测量类型且必填字段齐全
+func validateValues(t database.MeasurementType, values map[string]float64) error {
+	for key, v := range values {
+		if !slices.Contains(t.Fields, key) {
+			return fmt.Errorf("%s不支持数值字段 %s", t.Name, key)
+		}
+		if v <= 0 {
+			return fmt.Errorf("%s数值必须大于0", t.Name)
+		}
+	}
+	for _, key := range t.Required {
+		if _, ok := values[key]; !ok {
+			return fmt.Errorf("请填写%s数值 %s", t.Name, key)
+		}
+	}
+	return nil
+}
+
+// CreateMeasurement 创建测量记录。血压、心率、身高、体重、腰围写入健康记录，其余类型写入通用测量记录
+func (h *Handler) CreateMeasurement(c *gin.Context) {
+	var req models.CreateMeasurementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "数据格式错误"})
+		return
+	}
+
+	t, ok := database.LookupMeasurementType(req.Type)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的测量类型"})
+		return
+	}
+	if err := validateValues(t, req.Values); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if t.Type == database.MeasureBloodGlucose {
+		// 血糖支持 mg/dL 输入，统一换算为 mmol/L 保存
+		if err := normalizeGlucose(req.Values, req.Unit, req.Context); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if req.Unit != "" && req.Unit != t.Unit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s的单位必须为 %s", t.Name, t.Unit)})
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+	recordTime := time.Now().In(loc)
+	if req.RecordTime != "" {
+		rt, err := parseRecordTime(req.RecordTime, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		recordTime = rt
+	}
+
+	m := database.Measurement{
+		UserID:     userID,
+		Type:       t.Type,
+		Values:     req.Values,
+		Unit:       t.Unit,
+		Context:    req.Context,
+		RecordTime: recordTime,
+		Notes:      req.Notes,
+	}
+
+	resp := gin.H{
+		"message": "记录成功",
+		"time":    recordTime.In(loc).Format("2006-01-02 15:04"),
+	}
+	if bp, ok := database.BPFromMeasurement(m); ok {
+		id, err := h.db.CreateBPRecord(&bp)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+			return
+		}
+		record, alerts := h.bpCreated(bp, loc)
+		resp["id"] = id
+		resp["source"] = database.SourceBP
+		resp["status"] = record.Status
+		resp["alerts"] = alerts
+	} else {
+		id, err := h.db.CreateMeasurement(&m)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+			return
+		}
+		h.measurementCreated(m, loc)
+		resp["id"] = id
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// rejectBPSource 由健康记录映射的数据（source=bp）只能通过 /api/bp/:id 修改或删除：
+// 一条健康记录对应多个指标，不能通过其中一个指标修改或删除整条记录
+func rejectBPSource(c *gin.Context) bool {
+	switch c.Query("source") {
+	case "":
+		return false
+	case database.SourceBP:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "由健康记录映射的数据请通过 /api/bp/:id 修改或删除"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的记录来源"})
+	}
+	return true
+}
+
+// UpdateMeasurement 修改通用测量记录，由健康记录映射的数据（source=bp）请通过 /api/bp/:id 修改
+func (h *Handler) UpdateMeasurement(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	if rejectBPSource(c) {
+		return
+	}
+
+	var id int64
+	fmt.Sscanf(c.Param("id"), "%d", &id)
+
+	var req models.UpdateMeasurementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "数据格式错误"})
+		return
+	}
+
+	m, err := h.db.GetMeasurement(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		return
+	}
+	loc := h.userLocation(userID)
+
+	if req.Values != nil {
+		t, _ := database.LookupMeasurementType(m.Type)
+		if err := validateValues(t, req.Values); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		m.Values = req.Values
+	}
+	if req.Context != nil {
+		m.Context = *req.Context
+	}
+	if req.Notes != nil {
+		m.Notes = *req.Notes
+	}
+	if req.RecordTime != nil {
+		t, err := parseRecordTime(*req.RecordTime, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		m.RecordTime = t
+	}
+	if m.Type == database.MeasureBloodGlucose {
+		if err := normalizeGlucose(m.Values, health.UnitMmolL, m.Context); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.db.UpdateMeasurement(m); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+
+	m.RecordTime = m.RecordTime.In(loc)
+	c.JSON(http.StatusOK, gin.H{"message": "修改成功", "measurement": m})
+}
+
+// DeleteMeasurement 删除通用测量记录，由健康记录映射的数据（source=bp）请通过 /api/bp/:id 删除
+func (h *Handler) DeleteMeasurement(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	if rejectBPSource(c) {
+		return
+	}
+
+	var id int64
+	fmt.Sscanf(c.Param("id"), "%d", &id)
+
+	m, err := h.db.GetMeasurement(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		return
+	}
+	if err := h.db.DeleteMeasurement(id, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		return
+	}
+	h.measurementDeleted(*m)
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// measurementCreated 发送通用测量记录的 record.created 事件，数据中的 type 用于区分健康记录
+func (h *Handler) measurementCreated(m database.Measurement, loc *time.Location) {
+	m.RecordTime = m.RecordTime.In(loc)
+	h.notifier.Publish(notify.Event{Type: notify.EventRecordCreated, UserID: m.UserID, Data: m})
+}
+
+// measurementDeleted 发送通用测量记录的 record.deleted 事件
+func (h *Handler) measurementDeleted(m database.Measurement) {
+	h.notifier.Publish(notify.Event{Type: notify.EventRecordDeleted, UserID: m.UserID, Data: gin.H{"id": m.ID, "type": m.Type}})
+}
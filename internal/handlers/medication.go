@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"health-manager/internal/database"
+	"health-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// normalizeTimes 校验计划服药时间（HH:MM），去重后按时间排序
+func normalizeTimes(times []string) ([]string, error) {
+	seen := map[string]bool{}
+	list := []string{}
+	for _, s := range times {
+		t, err := time.Parse("15:04", strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("服药时间格式错误，应为 HH:MM")
+		}
+		s = t.Format("15:04")
+		if !seen[s] {
+			seen[s] = true
+			list = append(list, s)
+		}
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// GetMedications 获取药物列表，正在服用的在前
+func (h *Handler) GetMedications(c *gin.Context) {
+	list, err := h.db.GetMedications(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	if list == nil {
+		list = []database.Medication{}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].StoppedAt == nil && list[j].StoppedAt != nil
+	})
+	c.JSON(http.StatusOK, gin.H{"medications": list})
+}
+
+// CreateMedication 添加药物
+func (h *Handler) CreateMedication(c *gin.Context) {
+	var req models.MedicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "数据格式错误"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写药名"})
+		return
+	}
+	times, err := normalizeTimes(req.Times)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	med := database.Medication{
+		UserID:   c.GetInt64("user_id"),
+		Name:     req.Name,
+		Dose:     strings.TrimSpace(req.Dose),
+		Schedule: strings.TrimSpace(req.Schedule),
+		Times:    times,
+		Notes:    req.Notes,
+	}
+	if _, err := h.db.CreateMedication(&med); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "添加成功", "medication": med})
+}
+
+// UpdateMedication 修改药物，只更新请求中提供的字段；active=false 停用，true 恢复服用
+func (h *Handler) UpdateMedication(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var id int64
+	fmt.Sscanf(c.Param("id"), "%d", &id)
+
+	var req models.UpdateMedicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "数据格式错误"})
+		return
+	}
+
+	med, err := h.db.GetMedication(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "药物不存在"})
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请填写药名"})
+			return
+		}
+		med.Name = name
+	}
+	if req.Dose != nil {
+		med.Dose = strings.TrimSpace(*req.Dose)
+	}
+	if req.Schedule != nil {
+		med.Schedule = strings.TrimSpace(*req.Schedule)
+	}
+	if req.Times != nil {
+		times, err := normalizeTimes(*req.Times)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		med.Times = times
+	}
+	if req.Notes != nil {
+		med.Notes = *req.Notes
+	}
+	if req.Active != nil {
+		if !*req.Active && med.StoppedAt == nil {
+			now := time.Now()
+			med.StoppedAt = &now
+		} else if *req.Active {
+			med.StoppedAt = nil
+		}
+	}
+
+	if err := h.db.UpdateMedication(med); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "修改成功", "medication": med})
+}
+
+// DeleteMedication 删除药物，已记录的服药历史保留
+func (h *Handler) DeleteMedication(c *gin.Context) {
+	var id int64
+	fmt.Sscanf(c.Param("id"), "%d", &id)
+
+	if err := h.db.DeleteMedication(id, c.GetInt64("user_id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "药物不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetIntakes 获取服药记录，日期范围与 /api/bp 相同
+func (h *Handler) GetIntakes(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+
+	start, end, err := dateRange(c.Query("start_date"), c.Query("end_date"), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.db.GetIntakes(userID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	if list == nil {
+		list = []database.MedicationIntake{}
+	}
+	for i := range list {
+		list[i].TakenAt = list[i].TakenAt.In(loc)
+	}
+	c.JSON(http.StatusOK, gin.H{"intakes": list})
+}
+
+// CreateIntake 记录一次服药。关联药物时复制药名和剂量，也可不关联药物直接填写药名
+func (h *Handler) CreateIntake(c *gin.Context) {
+	var req models.CreateIntakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "数据格式错误"})
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+	in := database.MedicationIntake{
+		UserID:       userID,
+		MedicationID: req.MedicationID,
+		Name:         strings.TrimSpace(req.Name),
+		Dose:         strings.TrimSpace(req.Dose),
+		TakenAt:      time.Now().In(loc),
+		Notes:        req.Notes,
+	}
+
+	if req.MedicationID != 0 {
+		med, err := h.db.GetMedication(req.MedicationID, userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "药物不存在"})
+			return
+		}
+		in.Name = med.Name
+		if in.Dose == "" {
+			in.Dose = med.Dose
+		}
+	}
+	if in.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择药物或填写药名"})
+		return
+	}
+	if req.TakenAt != "" {
+		t, err := parseRecordTime(req.TakenAt, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		in.TakenAt = t
+	}
+
+	if _, err := h.db.CreateIntake(&in); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+	in.TakenAt = in.TakenAt.In(loc)
+	c.JSON(http.StatusOK, gin.H{"message": "记录成功", "intake": in})
+}
+
+// DeleteIntake 删除服药记录
+func (h *Handler) DeleteIntake(c *gin.Context) {
+	var id int64
+	fmt.Sscanf(c.Param("id"), "%d", &id)
+
+	if err := h.db.DeleteIntake(id, c.GetInt64("user_id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// timelineEntry 时间线中的一项：健康记录或服药记录
+type timelineEntry struct {
+	Type   string                     `json:"type"` // bp 或 intake
+	Time   time.Time                  `json:"time"`
+	Record *bpRecord                  `json:"record,omitempty"`
+	Intake *database.MedicationIntake `json:"intake,omitempty"`
+}
+
+// GetTimeline 按时间倒序返回健康记录与服药记录的合并时间线，便于对照服药前后的血压
+func (h *Handler) GetTimeline(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+
+	start, end, err := dateRange(c.Query("start_date"), c.Query("end_date"), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	records, err := h.db.GetBPRecords(userID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	intakes, err := h.db.GetIntakes(userID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	annotated := h.annotateRecords(userID, records, loc)
+
+	// 两个列表均已按时间倒序，依次归并；时间相同时服药记录排在健康记录之后（即先服药）
+	entries := make([]timelineEntry, 0, len(records)+len(intakes))
+	i, j := 0, 0
+	for i < len(records) || j < len(intakes) {
+		if j >= len(intakes) || (i < len(records) && !records[i].RecordTime.Before(intakes[j].TakenAt)) {
+			record := annotated[i]
+			entries = append(entries, timelineEntry{Type: "bp", Time: record.RecordTime, Record: &record})
+			i++
+		} else {
+			in := intakes[j]
+			in.TakenAt = in.TakenAt.In(loc)
+			entries = append(entries, timelineEntry{Type: "intake", Time: in.TakenAt, Intake: &in})
+			j++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"timeline": entries})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"health-manager/internal/health"
+	"health-manager/internal/reminder"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReminders 获取当前用户的测量提醒
+func (h *Handler) GetReminders(c *gin.Context) {
+	reminders := reminder.Load(h.db, c.GetInt64("user_id"))
+	if reminders == nil {
+		reminders = []health.Reminder{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"reminders":      reminders,
+		"default_window": health.DefaultReminderWindow,
+	})
+}
+
+// SetReminders 设置当前用户的测量提醒，为空时关闭提醒
+func (h *Handler) SetReminders(c *gin.Context) {
+	var req struct {
+		Reminders []health.Reminder `json:"reminders"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+	if err := health.ValidateReminders(req.Reminders); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	value := ""
+	if len(req.Reminders) > 0 {
+		sort.Slice(req.Reminders, func(i, j int) bool { return req.Reminders[i].Time < req.Reminders[j].Time })
+		data, _ := json.Marshal(req.Reminders)
+		value = string(data)
+	}
+	if err := h.db.SetUserSetting(c.GetInt64("user_id"), reminder.SettingKey, value); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
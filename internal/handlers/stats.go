@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"health-manager/internal/database"
+	"health-manager/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 统计的时间粒度
+const (
+	bucketDay   = "day"
+	bucketWeek  = "week"
+	bucketMonth = "month"
+)
+
+// bucketStart 返回 t 所在统计区间的起点（用户时区），周从周一开始
+func bucketStart(t time.Time, bucket string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch bucket {
+	case bucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case bucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// bucketEnd 返回统计区间的下一个区间起点
+func bucketEnd(start time.Time, bucket string) time.Time {
+	switch bucket {
+	case bucketWeek:
+		return start.AddDate(0, 0, 7)
+	case bucketMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// categoryShare 某一血压分级的读数条数及占比
+type categoryShare struct {
+	health.BPStatus
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// readingSummary 一组血压读数的统计
+type readingSummary struct {
+	Count     int             `json:"count"`
+	Systolic  *health.Summary `json:"systolic"`
+	Diastolic *health.Summary `json:"diastolic"`
+	HeartRate *health.Summary `json:"heart_rate,omitempty"`
+}
+
+// readings 累计的血压读数，收缩压、舒张压和心率各自只累计填写了的记录
+type readings struct {
+	count                          int
+	systolic, diastolic, heartRate []float64
+}
+
+func (r *readings) add(bp database.BloodPressure) {
+	r.count++
+	if bp.Systolic > 0 {
+		r.systolic = append(r.systolic, float64(bp.Systolic))
+	}
+	if bp.Diastolic > 0 {
+		r.diastolic = append(r.diastolic, float64(bp.Diastolic))
+	}
+	if bp.HeartRate > 0 {
+		r.heartRate = append(r.heartRate, float64(bp.HeartRate))
+	}
+}
+
+// merge 加入另一组读数
+func (r *readings) merge(other *readings) {
+	r.count += other.count
+	r.systolic = append(r.systolic, other.systolic...)
+	r.diastolic = append(r.diastolic, other.diastolic...)
+	r.heartRate = append(r.heartRate, other.heartRate...)
+}
+
+func (r *readings) summary() readingSummary {
+	return readingSummary{
+		Count:     r.count,
+		Systolic:  health.Summarize(r.systolic),
+		Diastolic: health.Summarize(r.diastolic),
+		HeartRate: health.Summarize(r.heartRate),
+	}
+}
+
+// bpStats 一个统计区间（或整个查询范围）内的血压统计
+type bpStats struct {
+	Start string `json:"start"` // 区间首日
+	End   string `json:"end"`   // 区间末日
+	readingSummary
+	Categories []categoryShare `json:"categories"`
+}
+
+// statsCollector 累计一个区间内的读数及各分级条数
+type statsCollector struct {
+	readings
+	start, end time.Time
+	levels     map[string]int
+}
+
+func newStatsCollector(start, end time.Time) *statsCollector {
+	return &statsCollector{start: start, end: end, levels: map[string]int{}}
+}
+
+// add 加入一条健康记录，status 为该记录的血压分级
+func (s *statsCollector) add(bp database.BloodPressure, status *health.BPStatus) {
+	s.readings.add(bp)
+	s.levels[status.Level]++
+}
+
+// result 计算统计结果，levels 为指南的全部分级（未出现的分级条数为0）
+func (s *statsCollector) result(levels []health.BPStatus) bpStats {
+	count := s.count
+	categories := make([]categoryShare, 0, len(levels))
+	for _, l := range levels {
+		share := categoryShare{BPStatus: l, Count: s.levels[l.Level]}
+		if count > 0 {
+			share.Percent = math.Round(float64(share.Count)/float64(count)*1000) / 10
+		}
+		categories = append(categories, share)
+	}
+	return bpStats{
+		Start:          s.start.Format("2006-01-02"),
+		End:            s.end.AddDate(0, 0, -1).Format("2006-01-02"),
+		readingSummary: s.summary(),
+		Categories:     categories,
+	}
+}
+
+// GetBPStats 按日、周或月统计血压：均值、最值、标准差、读数条数及各分级占比。
+// 区间按用户时区划分，只统计填写了血压的记录，没有读数的区间不返回
+func (h *Handler) GetBPStats(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	loc := h.userLocation(userID)
+
+	bucket := c.DefaultQuery("bucket", bucketDay)
+	if bucket != bucketDay && bucket != bucketWeek && bucket != bucketMonth {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bucket 必须为 day、week 或 month"})
+		return
+	}
+
+	start, end, err := dateRange(c.Query("start_date"), c.Query("end_date"), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	records, err := h.db.GetBPRecords(userID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	guideline := h.userGuideline(userID)
+	levels := health.BPLevels(guideline)
+
+	// 记录按时间倒序，从最早的开始归入区间，使结果按时间正序
+	var buckets []*statsCollector
+	var overall *statsCollector
+	for i := len(records) - 1; i >= 0; i-- {
+		bp := records[i]
+		status := health.ClassifyBP(bp.Systolic, bp.Diastolic, guideline)
+		if status == nil {
+			continue
+		}
+		t := bp.RecordTime.In(loc)
+		if n := len(buckets); n == 0 || !t.Before(buckets[n-1].end) {
+			bs := bucketStart(t, bucket)
+			buckets = append(buckets, newStatsCollector(bs, bucketEnd(bs, bucket)))
+		}
+		buckets[len(buckets)-1].add(bp, status)
+
+		if overall == nil {
+			overall = newStatsCollector(bucketStart(t, bucketDay), time.Time{})
+		}
+		overall.end = bucketEnd(bucketStart(t, bucketDay), bucketDay)
+		overall.add(bp, status)
+	}
+
+	result := make([]bpStats, 0, len(buckets))
+	for _, b := range buckets {
+		result = append(result, b.result(levels))
+	}
+	var summary *bpStats
+	if overall != nil {
+		s := overall.result(levels)
+		summary = &s
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bucket":    bucket,
+		"guideline": guideline,
+		"buckets":   result,
+		"overall":   summary,
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBucketStart(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		at, bucket string
+		start, end string
+	}{
+		{"2024-03-06 15:04", bucketDay, "2024-03-06", "2024-03-07"},
+		{"2024-03-06 00:00", bucketDay, "2024-03-06", "2024-03-07"},
+		{"2024-03-06 15:04", bucketWeek, "2024-03-04", "2024-03-11"}, // 周三
+		{"2024-03-04 00:00", bucketWeek, "2024-03-04", "2024-03-11"}, // 周一
+		{"2024-03-10 23:59", bucketWeek, "2024-03-04", "2024-03-11"}, // 周日属于前一个周一开始的一周
+		{"2024-01-03 08:00", bucketWeek, "2024-01-01", "2024-01-08"},
+		{"2023-12-31 08:00", bucketWeek, "2023-12-25", "2024-01-01"}, // 跨年
+		{"2024-02-29 08:00", bucketMonth, "2024-02-01", "2024-03-01"},
+		{"2024-12-31 23:00", bucketMonth, "2024-12-01", "2025-01-01"},
+		{"2024-03-10 12:00", bucketDay, "2024-03-10", "2024-03-11"}, // 夏令时开始，当天只有23小时
+	}
+	for _, tt := range tests {
+		at, err := time.ParseInLocation("2006-01-02 15:04", tt.at, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := bucketStart(at, tt.bucket)
+		end := bucketEnd(start, tt.bucket)
+		if start.Format("2006-01-02 15:04") != tt.start+" 00:00" || end.Format("2006-01-02 15:04") != tt.end+" 00:00" {
+			t.Errorf("%s %s: [%v, %v), want [%s, %s)", tt.bucket, tt.at, start, end, tt.start, tt.end)
+		}
+		if at.Before(start) || !at.Before(end) {
+			t.Errorf("%s %s: not in [%v, %v)", tt.bucket, tt.at, start, end)
+		}
+	}
+}
+
+func TestGetBPStats(t *testing.T) {
+	s := newTestServer(t)
+	cookies := s.login()
+	if err := s.db.UpdateUserTimeZone(1, "Asia/Shanghai"); err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []gin.H{
+		{"systolic": 120, "diastolic": 80, "heart_rate": 70, "record_time": "2024-03-04 08:00"},
+		{"systolic": 140, "diastolic": 90, "record_time": "2024-03-05 08:00"},
+		{"systolic": 130, "diastolic": 85, "heart_rate": 74, "record_time": "2024-03-10 23:30"},
+		{"height": 170, "weight": 65, "record_time": "2024-03-10 23:40"}, // 没有血压，不参与统计
+		{"systolic": 165, "diastolic": 100, "record_time": "2024-03-11 00:10"},
+	} {
+		if w := s.do(http.MethodPost, "/api/bp", body, cookies); w.Code != http.StatusOK {
+			t.Fatalf("create %v: %d %s", body, w.Code, w.Body)
+		}
+	}
+
+	w := s.do(http.MethodGet, "/api/bp/stats?bucket=week", nil, cookies)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code = %d (%s)", w.Code, w.Body)
+	}
+	var resp struct {
+		Buckets []bpStats `json:"buckets"`
+		Overall *bpStats  `json:"overall"`
+	}
+	decode(t, w, &resp)
+
+	if len(resp.Buckets) != 2 {
+		t.Fatalf("buckets = %+v, want 2 weeks", resp.Buckets)
+	}
+	week := resp.Buckets[0]
+	if week.Start != "2024-03-04" || week.End != "2024-03-10" || week.Count != 3 {
+		t.Errorf("first week = %s..%s count %d, want 2024-03-04..2024-03-10 count 3", week.Start, week.End, week.Count)
+	}
+	if sys := week.Systolic; sys == nil || sys.Mean != 130 || sys.StdDev != 10 || sys.Min != 120 || sys.Max != 140 {
+		t.Errorf("first week systolic = %+v", sys)
+	}
+	if hr := week.HeartRate; hr == nil || hr.Count != 2 || hr.Mean != 72 {
+		t.Errorf("first week heart rate = %+v", hr)
+	}
+	shares := map[string]categoryShare{}
+	for _, c := range week.Categories {
+		shares[c.Level] = c
+	}
+	if c := shares["high_normal"]; c.Count != 2 || c.Percent != 66.7 {
+		t.Errorf("high_normal = %+v, want 2 (66.7%%)", c)
+	}
+	if c := shares["grade1"]; c.Count != 1 || c.Percent != 33.3 {
+		t.Errorf("grade1 = %+v, want 1 (33.3%%)", c)
+	}
+	if c, ok := shares["grade3"]; !ok || c.Count != 0 {
+		t.Errorf("grade3 = %+v, want listed with 0 readings", c)
+	}
+
+	if next := resp.Buckets[1]; next.Start != "2024-03-11" || next.Count != 1 {
+		t.Errorf("second week = %s count %d, want 2024-03-11 count 1", next.Start, next.Count)
+	}
+	if o := resp.Overall; o == nil || o.Count != 4 || o.Start != "2024-03-04" || o.End != "2024-03-11" {
+		t.Errorf("overall = %+v", o)
+	}
+
+	if w := s.do(http.MethodGet, "/api/bp/stats?bucket=year", nil, cookies); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid bucket: code = %d, want 400", w.Code)
+	}
+}
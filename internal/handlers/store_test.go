@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"health-manager/internal/database"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeStore 内存中的 database.Store，只实现测试用到的用户、健康记录、提醒事件和设置操作，
+// 其余方法由嵌入的 nil 接口提供，调用时会 panic
+type fakeStore struct {
+	database.Store
+
+	mu           sync.Mutex
+	users        []database.User
+	records      []database.BloodPressure
+	alerts       []database.Alert
+	settings     map[string]string
+	userSettings map[int64]map[string]string
+	nextID       int64
+}
+
+// newFakeStore 创建只有默认管理员 admin/admin123 的内存存储
+func newFakeStore() *fakeStore {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.MinCost)
+	return &fakeStore{
+		users:        []database.User{{ID: 1, Username: "admin", Password: string(hash), Role: "admin", CreatedAt: time.Now()}},
+		settings:     map[string]string{},
+		userSettings: map[int64]map[string]string{},
+		nextID:       1,
+	}
+}
+
+func (s *fakeStore) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *fakeStore) user(id int64) *database.User {
+	for i := range s.users {
+		if s.users[i].ID == id {
+			return &s.users[i]
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) GetUserByUsername(username string) (*database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Username == username {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (s *fakeStore) GetUserTimeZone(id int64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u := s.user(id); u != nil {
+		return u.TimeZone
+	}
+	return ""
+}
+
+func (s *fakeStore) UpdateUserTimeZone(id int64, timeZone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.user(id)
+	if u == nil {
+		return fmt.Errorf("user not found")
+	}
+	u.TimeZone = timeZone
+	return nil
+}
+
+func (s *fakeStore) CreateBPRecord(bp *database.BloodPressure) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bp.ID = s.id()
+	bp.CreatedAt = time.Now()
+	s.records = append(s.records, *bp)
+	return bp.ID, nil
+}
+
+func (s *fakeStore) GetBPRecords(userID int64, start, end time.Time) ([]database.BloodPressure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []database.BloodPressure
+	for _, bp := range s.records {
+		if bp.UserID != userID || (!start.IsZero() && bp.RecordTime.Before(start)) || (!end.IsZero() && !bp.RecordTime.Before(end)) {
+			continue
+		}
+		list = append(list, bp)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].RecordTime.After(list[j].RecordTime) })
+	return list, nil
+}
+
+func (s *fakeStore) GetBPRecord(id, userID int64) (*database.BloodPressure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, bp := range s.records {
+		if bp.ID == id && bp.UserID == userID {
+			return &bp, nil
+		}
+	}
+	return nil, fmt.Errorf("record not found")
+}
+
+func (s *fakeStore) CreateAlert(a *database.Alert) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.ID = s.id()
+	a.CreatedAt = time.Now()
+	s.alerts = append(s.alerts, *a)
+	return a.ID, nil
+}
+
+func (s *fakeStore) GetSetting(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings[key], nil
+}
+
+func (s *fakeStore) SetSetting(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings[key] = value
+	return nil
+}
+
+func (s *fakeStore) GetUserSetting(userID int64, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.userSettings[userID][key], nil
+}
+
+func (s *fakeStore) SetUserSetting(userID int64, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.userSettings[userID] == nil {
+		s.userSettings[userID] = map[string]string{}
+	}
+	if value == "" {
+		delete(s.userSettings[userID], key)
+	} else {
+		s.userSettings[userID][key] = value
+	}
+	return nil
+}
+
+func (s *fakeStore) Close() error { return nil }
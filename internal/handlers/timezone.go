@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultTimeZone 未配置服务器默认时区时使用的时区
+const defaultTimeZone = "Asia/Shanghai"
+
+// loadLocation 加载IANA时区，系统缺少时区数据时北京时间退化为固定UTC+8
+func loadLocation(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil && name == defaultTimeZone {
+		return time.FixedZone("CST", 8*3600), nil
+	}
+	return loc, err
+}
+
+// defaultTimeZoneName 获取服务器默认时区名
+func (h *Handler) defaultTimeZoneName() string {
+	if name, _ := h.db.GetSetting("default_time_zone"); name != "" {
+		return name
+	}
+	return defaultTimeZone
+}
+
+// defaultLocation 获取服务器默认时区
+func (h *Handler) defaultLocation() *time.Location {
+	if loc, err := loadLocation(h.defaultTimeZoneName()); err == nil {
+		return loc
+	}
+	loc, _ := loadLocation(defaultTimeZone)
+	return loc
+}
+
+// userLocation 获取用户时区，用户未设置时使用服务器默认时区
+func (h *Handler) userLocation(userID int64) *time.Location {
+	if name := h.db.GetUserTimeZone(userID); name != "" {
+		if loc, err := loadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return h.defaultLocation()
+}
+
+// UserLocation 获取用户时区，供后台任务（如测量提醒）使用
+func (h *Handler) UserLocation(userID int64) *time.Location {
+	return h.userLocation(userID)
+}
+
+// dateRange 将 "2006-01-02" 格式的起止日期按loc换算为 [start, end) 时间范围，空字符串表示不限
+func dateRange(startDate, endDate string, loc *time.Location) (start, end time.Time, err error) {
+	if startDate != "" {
+		if start, err = time.ParseInLocation("2006-01-02", startDate, loc); err != nil {
+			return start, end, fmt.Errorf("日期格式错误")
+		}
+	}
+	if endDate != "" {
+		if end, err = time.ParseInLocation("2006-01-02", endDate, loc); err != nil {
+			return start, end, fmt.Errorf("日期格式错误")
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// GetTimeZone 获取当前用户时区及服务器默认时区
+func (h *Handler) GetTimeZone(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	c.JSON(http.StatusOK, gin.H{
+		"time_zone":         h.db.GetUserTimeZone(userID),
+		"default_time_zone": h.defaultTimeZoneName(),
+	})
+}
+
+// SetTimeZone 设置当前用户时区，为空时恢复使用服务器默认时区
+func (h *Handler) SetTimeZone(c *gin.Context) {
+	var req struct {
+		TimeZone string `json:"time_zone"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+
+	if req.TimeZone != "" {
+		if _, err := loadLocation(req.TimeZone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时区"})
+			return
+		}
+	}
+
+	userID := c.GetInt64("user_id")
+	if err := h.db.UpdateUserTimeZone(userID, req.TimeZone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
+
+// SetDefaultTimeZone 设置服务器默认时区
+func (h *Handler) SetDefaultTimeZone(c *gin.Context) {
+	var req struct {
+		TimeZone string `json:"time_zone"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.TimeZone == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数"})
+		return
+	}
+
+	if _, err := loadLocation(req.TimeZone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时区"})
+		return
+	}
+
+	if err := h.db.SetSetting("default_time_zone", req.TimeZone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "设置已保存"})
+}
@@ -6,24 +6,15 @@ import (
 	"time"
 
 	"health-manager/internal/database"
+	"health-manager/internal/health"
 	"health-manager/internal/models"
+	"health-manager/internal/notify"
 
 	"github.com/gin-gonic/gin"
 )
 
-// 北京时区
-var beijingLoc *time.Location
-
-func init() {
-	var err error
-	beijingLoc, err = time.LoadLocation("Asia/Shanghai")
-	if err != nil {
-		beijingLoc = time.FixedZone("CST", 8*3600)
-	}
-}
-
 // CreateBP 创建健康记录
-func CreateBP(c *gin.Context) {
+func (h *Handler) CreateBP(c *gin.Context) {
 	var req models.CreateBPRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "数据格式错误"})
@@ -40,53 +31,225 @@ func CreateBP(c *gin.Context) {
 	}
 
 	userID := c.GetInt64("user_id")
-	recordTime := time.Now().In(beijingLoc)
+	loc := h.userLocation(userID)
+	recordTime := time.Now().In(loc)
+	if req.RecordTime != "" {
+		t, err := parseRecordTime(req.RecordTime, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		recordTime = t
+	}
 
-	id, err := database.CreateBPRecord(userID, req.Systolic, req.Diastolic, req.HeartRate, req.Height, req.Weight, req.Waistline, recordTime, req.Notes)
+	bp := database.BloodPressure{
+		UserID:     userID,
+		Systolic:   req.Systolic,
+		Diastolic:  req.Diastolic,
+		HeartRate:  req.HeartRate,
+		Height:     req.Height,
+		Weight:     req.Weight,
+		Waistline:  req.Waistline,
+		RecordTime: recordTime,
+		Notes:      req.Notes,
+	}
+	id, err := h.db.CreateBPRecord(&bp)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
 		return
 	}
 
+	record, alerts := h.bpCreated(bp, loc)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "记录成功",
 		"id":      id,
-		"time":    recordTime.Format("2006-01-02 15:04"),
+		"time":    recordTime.In(loc).Format("2006-01-02 15:04"),
+		"status":  record.Status,
+		"alerts":  alerts,
 	})
 }
 
+// bpCreated 健康记录保存后的处理：标注血压分级、发送 record.created 事件并检查提醒规则
+func (h *Handler) bpCreated(bp database.BloodPressure, loc *time.Location) (bpRecord, []database.Alert) {
+	record := h.annotateRecords(bp.UserID, []database.BloodPressure{bp}, loc)[0]
+	h.notifier.Publish(notify.Event{Type: notify.EventRecordCreated, UserID: bp.UserID, Data: record})
+	return record, h.checkAlerts(bp)
+}
+
+// recordTimeLayouts 不带时区的测量时间格式，按用户时区解析
+var recordTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// parseRecordTime 解析用户提交的测量时间，不能晚于当前时间（允许1分钟误差）
+func parseRecordTime(s string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		for _, layout := range recordTimeLayouts {
+			if t, err = time.ParseInLocation(layout, s, loc); err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("测量时间格式错误")
+	}
+	if t.After(time.Now().Add(time.Minute)) {
+		return time.Time{}, fmt.Errorf("测量时间不能晚于当前时间")
+	}
+	return t, nil
+}
+
+// bpRecord 附带血压分级和身体指标的健康记录
+type bpRecord struct {
+	database.BloodPressure
+	Status *health.BPStatus    `json:"status,omitempty"`
+	Body   *health.BodyMetrics `json:"body,omitempty"`
+}
+
+// lastHeight 获取用户在 before 之前最近一次记录的身高，没有时返回0
+func (h *Handler) lastHeight(userID int64, before time.Time) float64 {
+	records, _ := h.db.GetBPRecords(userID, time.Time{}, before)
+	for _, bp := range records {
+		if bp.Height > 0 {
+			return bp.Height
+		}
+	}
+	return 0
+}
+
+// annotateRecords 按用户选择的指南和标准为记录计算血压分级与身体指标，并转换为用户时区。
+// records 须按时间倒序；只有体重或腰围的记录使用此前最近一次记录的身高
+func (h *Handler) annotateRecords(userID int64, records []database.BloodPressure, loc *time.Location) []bpRecord {
+	guideline := h.userGuideline(userID)
+	standard := h.userBMIStandard(userID)
+
+	list := make([]bpRecord, len(records))
+	height, searched := 0.0, false
+	for i := len(records) - 1; i >= 0; i-- {
+		bp := records[i]
+		fromHistory := bp.Height <= 0
+		if !fromHistory {
+			height = bp.Height
+		} else if height == 0 && !searched && (bp.Weight > 0 || bp.Waistline > 0) {
+			// 区间内最早的记录之前的身高只需查询一次
+			height, searched = h.lastHeight(userID, bp.RecordTime), true
+		}
+
+		bp.RecordTime = bp.RecordTime.In(loc)
+		list[i] = bpRecord{
+			BloodPressure: bp,
+			Status:        health.ClassifyBP(bp.Systolic, bp.Diastolic, guideline),
+			Body:          health.BodyComposition(height, bp.Weight, bp.Waistline, fromHistory, standard),
+		}
+	}
+	return list
+}
+
 // GetBPRecords 获取血压记录
-func GetBPRecords(c *gin.Context) {
+func (h *Handler) GetBPRecords(c *gin.Context) {
 	userID := c.GetInt64("user_id")
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
+	loc := h.userLocation(userID)
 
-	records, err := database.GetBPRecords(userID, startDate, endDate)
+	// 起止日期按用户时区的自然日计算
+	start, end, err := dateRange(c.Query("start_date"), c.Query("end_date"), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	records, err := h.db.GetBPRecords(userID, start, end)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
 	}
 
-	// 转换时间为北京时间显示
-	for i := range records {
-		records[i].RecordTime = records[i].RecordTime.In(beijingLoc)
+	// 转换时间为用户时区显示，并计算血压分级与身体指标
+	c.JSON(http.StatusOK, gin.H{"records": h.annotateRecords(userID, records, loc)})
+}
+
+// UpdateBP 修改健康记录，只更新请求中提供的字段，创建时间保持不变
+func (h *Handler) UpdateBP(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var id int64
+	fmt.Sscanf(c.Param("id"), "%d", &id)
+
+	var req models.UpdateBPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "数据格式错误"})
+		return
+	}
+
+	bp, err := h.db.GetBPRecord(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		return
+	}
+	loc := h.userLocation(userID)
+
+	if req.Systolic != nil {
+		bp.Systolic = *req.Systolic
+	}
+	if req.Diastolic != nil {
+		bp.Diastolic = *req.Diastolic
+	}
+	if req.HeartRate != nil {
+		bp.HeartRate = *req.HeartRate
+	}
+	if req.Height != nil {
+		bp.Height = *req.Height
+	}
+	if req.Weight != nil {
+		bp.Weight = *req.Weight
+	}
+	if req.Waistline != nil {
+		bp.Waistline = *req.Waistline
+	}
+	if req.Notes != nil {
+		bp.Notes = *req.Notes
+	}
+	if req.RecordTime != nil {
+		t, err := parseRecordTime(*req.RecordTime, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		bp.RecordTime = t
+	}
+
+	// 与新建时相同，修改后仍需保留血压或身高体重之一
+	hasBP := bp.Systolic > 0 || bp.Diastolic > 0
+	hasBody := bp.Height > 0 || bp.Weight > 0
+	if !hasBP && !hasBody {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请至少保留血压或身高体重数据"})
+		return
+	}
+
+	if err := h.db.UpdateBPRecord(bp); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"records": records})
+	c.JSON(http.StatusOK, gin.H{"message": "修改成功", "record": h.annotateRecords(userID, []database.BloodPressure{*bp}, loc)[0]})
 }
 
 // DeleteBP 删除血压记录
-func DeleteBP(c *gin.Context) {
+func (h *Handler) DeleteBP(c *gin.Context) {
 	userID := c.GetInt64("user_id")
 	bpID := c.Param("id")
 
 	var id int64
 	fmt.Sscanf(bpID, "%d", &id)
 
-	if err := database.DeleteBPRecord(id, userID); err != nil {
+	if err := h.db.DeleteBPRecord(id, userID); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
 		return
 	}
+	h.notifier.Publish(notify.Event{Type: notify.EventRecordDeleted, UserID: userID, Data: gin.H{"id": id}})
 
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
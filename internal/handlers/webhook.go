@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"health-manager/internal/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webhookView 返回给前端的 Webhook，不包含密钥
+type webhookView struct {
+	notify.Webhook
+	HasSecret bool `json:"has_secret"`
+}
+
+// GetWebhooks 获取 Webhook 列表、可订阅的事件类型和最近的投递记录
+func (h *Handler) GetWebhooks(c *gin.Context) {
+	hooks, err := h.webhooks.LoadConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取 Webhook 配置失败"})
+		return
+	}
+	deliveries, err := h.webhooks.Deliveries()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取 Webhook 投递记录失败"})
+		return
+	}
+	views := make([]webhookView, 0, len(hooks))
+	for _, w := range hooks {
+		v := webhookView{Webhook: w, HasSecret: w.Secret != ""}
+		v.Secret = ""
+		views = append(views, v)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks":   views,
+		"events":     notify.Events,
+		"deliveries": deliveries,
+	})
+}
+
+// SaveWebhooks 保存 Webhook 列表，未填写密钥的沿用原密钥
+func (h *Handler) SaveWebhooks(c *gin.Context) {
+	var req struct {
+		Webhooks []notify.Webhook `json:"webhooks"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if req.Webhooks == nil {
+		req.Webhooks = []notify.Webhook{}
+	}
+
+	if err := h.webhooks.SaveConfig(req.Webhooks); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook 设置已保存"})
+}
+
+// TestWebhook 向指定 Webhook 发送一条测试事件
+func (h *Handler) TestWebhook(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	d, err := h.webhooks.Test(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !d.Success {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "发送失败: " + d.Error, "delivery": d})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "测试消息已送达", "delivery": d})
+}
@@ -0,0 +1,121 @@
+package health
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// 提醒规则可用的指标
+const (
+	AlertSystolic     = "systolic"
+	AlertDiastolic    = "diastolic"
+	AlertHeartRate    = "heart_rate"
+	AlertWeight       = "weight"
+	AlertWeightChange = "weight_change" // 与 Days 天内最早的体重相比的变化量，按绝对值比较
+)
+
+// DefaultChangeDays 体重变化规则未指定天数时的比较区间
+const DefaultChangeDays = 7
+
+// alertMetric 提醒指标的名称与单位
+type alertMetric struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+}
+
+// AlertMetrics 支持的提醒指标
+var AlertMetrics = []alertMetric{
+	{AlertSystolic, "收缩压", "mmHg"},
+	{AlertDiastolic, "舒张压", "mmHg"},
+	{AlertHeartRate, "心率", "次/分"},
+	{AlertWeight, "体重", "kg"},
+	{AlertWeightChange, "体重变化", "kg"},
+}
+
+// AlertOperators 支持的比较方式
+var AlertOperators = []string{">=", ">", "<=", "<"}
+
+// operatorSymbols 比较方式在提醒文字中的写法
+var operatorSymbols = map[string]string{">=": "≥", ">": ">", "<=": "≤", "<": "<"}
+
+// lookupAlertMetric 按ID查找提醒指标
+func lookupAlertMetric(id string) (alertMetric, bool) {
+	for _, m := range AlertMetrics {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return alertMetric{}, false
+}
+
+// AlertRule 提醒规则：新记录的指标与阈值比较成立时触发，如收缩压 ≥ 160
+type AlertRule struct {
+	Metric   string  `json:"metric"`
+	Operator string  `json:"operator"`
+	Value    float64 `json:"value"`
+	// Days 体重变化规则的比较区间（天），为0时使用 DefaultChangeDays
+	Days int `json:"days,omitempty"`
+}
+
+// ValidateAlertRules 校验提醒规则的指标、比较方式和阈值
+func ValidateAlertRules(rules []AlertRule) error {
+	for i, r := range rules {
+		if _, ok := lookupAlertMetric(r.Metric); !ok {
+			return fmt.Errorf("第%d条规则: 不支持的指标", i+1)
+		}
+		if _, ok := operatorSymbols[r.Operator]; !ok {
+			return fmt.Errorf("第%d条规则: 不支持的比较方式", i+1)
+		}
+		if r.Value <= 0 {
+			return fmt.Errorf("第%d条规则: 阈值必须大于0", i+1)
+		}
+		// 0 表示未填写，使用 DefaultChangeDays
+		if r.Days < 0 || r.Days > 365 {
+			return fmt.Errorf("第%d条规则: 天数应在 1–365 之间，不填写时为 %d 天", i+1, DefaultChangeDays)
+		}
+	}
+	return nil
+}
+
+// ChangeDays 体重变化规则的比较区间（天）
+func (r AlertRule) ChangeDays() int {
+	if r.Days > 0 {
+		return r.Days
+	}
+	return DefaultChangeDays
+}
+
+// Match 判断指标数值是否触发规则，体重变化按绝对值比较
+func (r AlertRule) Match(v float64) bool {
+	if r.Metric == AlertWeightChange {
+		v = math.Abs(v)
+	}
+	switch r.Operator {
+	case ">=":
+		return v >= r.Value
+	case ">":
+		return v > r.Value
+	case "<=":
+		return v <= r.Value
+	case "<":
+		return v < r.Value
+	}
+	return false
+}
+
+// Describe 生成提醒文字，如 "收缩压 182 mmHg，达到提醒条件 ≥ 160 mmHg"
+func (r AlertRule) Describe(v float64) string {
+	m, _ := lookupAlertMetric(r.Metric)
+	value := strconv.FormatFloat(v, 'f', -1, 64)
+	name := m.Name
+	if r.Metric == AlertWeightChange {
+		name = fmt.Sprintf("%d天内%s", r.ChangeDays(), m.Name)
+		if v > 0 {
+			value = "+" + value
+		}
+	}
+	return fmt.Sprintf("%s %s %s，达到提醒条件 %s %s %s", name, value, m.Unit,
+		operatorSymbols[r.Operator], strconv.FormatFloat(r.Value, 'f', -1, 64), m.Unit)
+}
@@ -0,0 +1,70 @@
+package health
+
+import "testing"
+
+func TestValidateAlertRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule AlertRule
+		ok   bool
+	}{
+		{"收缩压", AlertRule{Metric: AlertSystolic, Operator: ">=", Value: 160}, true},
+		{"心率过低", AlertRule{Metric: AlertHeartRate, Operator: "<", Value: 50}, true},
+		{"体重变化默认天数", AlertRule{Metric: AlertWeightChange, Operator: ">=", Value: 2}, true},
+		{"体重变化365天", AlertRule{Metric: AlertWeightChange, Operator: ">=", Value: 2, Days: 365}, true},
+		{"不支持的指标", AlertRule{Metric: "glucose", Operator: ">=", Value: 7}, false},
+		{"不支持的比较方式", AlertRule{Metric: AlertSystolic, Operator: "==", Value: 160}, false},
+		{"阈值为0", AlertRule{Metric: AlertSystolic, Operator: ">=", Value: 0}, false},
+		{"天数为负", AlertRule{Metric: AlertWeightChange, Operator: ">=", Value: 2, Days: -1}, false},
+		{"天数超过365", AlertRule{Metric: AlertWeightChange, Operator: ">=", Value: 2, Days: 366}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateAlertRules([]AlertRule{tt.rule}); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestAlertRuleMatch(t *testing.T) {
+	tests := []struct {
+		rule  AlertRule
+		value float64
+		want  bool
+	}{
+		{AlertRule{Metric: AlertSystolic, Operator: ">=", Value: 160}, 160, true},
+		{AlertRule{Metric: AlertSystolic, Operator: ">=", Value: 160}, 159, false},
+		{AlertRule{Metric: AlertSystolic, Operator: ">", Value: 160}, 160, false},
+		{AlertRule{Metric: AlertSystolic, Operator: ">", Value: 160}, 161, true},
+		{AlertRule{Metric: AlertHeartRate, Operator: "<=", Value: 50}, 50, true},
+		{AlertRule{Metric: AlertHeartRate, Operator: "<", Value: 50}, 50, false},
+		{AlertRule{Metric: AlertHeartRate, Operator: "<", Value: 50}, 49, true},
+		// 体重变化按绝对值比较，增减都会触发
+		{AlertRule{Metric: AlertWeightChange, Operator: ">=", Value: 2}, 2.5, true},
+		{AlertRule{Metric: AlertWeightChange, Operator: ">=", Value: 2}, -2.5, true},
+		{AlertRule{Metric: AlertWeightChange, Operator: ">=", Value: 2}, -1.5, false},
+		{AlertRule{Metric: AlertWeight, Operator: "<", Value: 50}, -1, true},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.Match(tt.value); got != tt.want {
+			t.Errorf("%s %s %v Match(%v) = %v, want %v", tt.rule.Metric, tt.rule.Operator, tt.rule.Value, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAlertRuleDescribe(t *testing.T) {
+	tests := []struct {
+		rule  AlertRule
+		value float64
+		want  string
+	}{
+		{AlertRule{Metric: AlertSystolic, Operator: ">=", Value: 160}, 182, "收缩压 182 mmHg，达到提醒条件 ≥ 160 mmHg"},
+		{AlertRule{Metric: AlertHeartRate, Operator: "<", Value: 50}, 45, "心率 45 次/分，达到提醒条件 < 50 次/分"},
+		{AlertRule{Metric: AlertWeightChange, Operator: ">=", Value: 2}, 2.4, "7天内体重变化 +2.4 kg，达到提醒条件 ≥ 2 kg"},
+		{AlertRule{Metric: AlertWeightChange, Operator: ">=", Value: 1.5, Days: 3}, -1.6, "3天内体重变化 -1.6 kg，达到提醒条件 ≥ 1.5 kg"},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.Describe(tt.value); got != tt.want {
+			t.Errorf("Describe(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
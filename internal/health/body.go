@@ -0,0 +1,105 @@
+package health
+
+import "math"
+
+// BMI 分类标准
+const (
+	BMIStandardWGOC = "wgoc" // 中国肥胖问题工作组（WGOC）
+	BMIStandardWHO  = "who"  // 世界卫生组织（WHO）
+)
+
+// DefaultBMIStandard 未设置时使用的 BMI 分类标准
+const DefaultBMIStandard = BMIStandardWGOC
+
+// centralObesityRatio 腰围身高比达到此值判定为中心型肥胖（记录中没有性别，不使用按性别区分的腰围切点）
+const centralObesityRatio = 0.5
+
+// bmiStandard 一套 BMI 分类切点：低于 underweight 为偏瘦，不低于 overweight 为超重，不低于 obese 为肥胖
+type bmiStandard struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	underweight float64
+	overweight  float64
+	obese       float64
+}
+
+// BMIStandards 支持的 BMI 分类标准
+var BMIStandards = []bmiStandard{
+	{ID: BMIStandardWGOC, Name: "中国标准（WGOC）", underweight: 18.5, overweight: 24, obese: 28},
+	{ID: BMIStandardWHO, Name: "WHO 标准", underweight: 18.5, overweight: 25, obese: 30},
+}
+
+// lookupBMIStandard 按ID查找 BMI 分类标准
+func lookupBMIStandard(id string) (bmiStandard, bool) {
+	for _, s := range BMIStandards {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return bmiStandard{}, false
+}
+
+// ValidBMIStandard 判断是否为支持的 BMI 分类标准
+func ValidBMIStandard(id string) bool {
+	_, ok := lookupBMIStandard(id)
+	return ok
+}
+
+// BMIStatus BMI 分类结果，Severity 含义与 BPStatus 相同：0 正常，1 偏离，2 肥胖
+type BMIStatus struct {
+	Standard string `json:"standard"`
+	Level    string `json:"level"`
+	Text     string `json:"text"`
+	Severity int    `json:"severity"`
+}
+
+// ClassifyBMI 按指定标准对 BMI 分类，标准无效时使用默认标准
+func ClassifyBMI(bmi float64, standard string) BMIStatus {
+	s, ok := lookupBMIStandard(standard)
+	if !ok {
+		s, _ = lookupBMIStandard(DefaultBMIStandard)
+	}
+	switch {
+	case bmi < s.underweight:
+		return BMIStatus{s.ID, "underweight", "偏瘦", 1}
+	case bmi < s.overweight:
+		return BMIStatus{s.ID, "normal", "正常", 0}
+	case bmi < s.obese:
+		return BMIStatus{s.ID, "overweight", "超重", 1}
+	}
+	return BMIStatus{s.ID, "obese", "肥胖", 2}
+}
+
+// BodyMetrics 由身高、体重、腰围计算的身体指标
+type BodyMetrics struct {
+	Height float64 `json:"height"` // 计算所用身高（cm）
+	// HeightFromHistory 本条记录未填身高，使用此前最近一次记录的身高
+	HeightFromHistory bool       `json:"height_from_history,omitempty"`
+	BMI               float64    `json:"bmi,omitempty"`
+	BMIStatus         *BMIStatus `json:"bmi_status,omitempty"`
+	WaistToHeight     float64    `json:"waist_to_height,omitempty"`
+	CentralObesity    *bool      `json:"central_obesity,omitempty"` // 腰围身高比 ≥ 0.5，未填腰围时为空
+}
+
+// BodyComposition 计算 BMI 及腰围身高比，身高为0或缺少体重、腰围时返回 nil。
+// fromHistory 表示 height 来自此前的记录
+func BodyComposition(height, weight, waistline float64, fromHistory bool, standard string) *BodyMetrics {
+	if height <= 0 || (weight <= 0 && waistline <= 0) {
+		return nil
+	}
+
+	m := &BodyMetrics{Height: height, HeightFromHistory: fromHistory}
+	if weight > 0 {
+		meters := height / 100
+		m.BMI = math.Round(weight/(meters*meters)*10) / 10
+		status := ClassifyBMI(m.BMI, standard)
+		m.BMIStatus = &status
+	}
+	if waistline > 0 {
+		ratio := waistline / height
+		m.WaistToHeight = math.Round(ratio*100) / 100
+		central := ratio >= centralObesityRatio
+		m.CentralObesity = &central
+	}
+	return m
+}
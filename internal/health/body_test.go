@@ -0,0 +1,80 @@
+package health
+
+import "testing"
+
+func TestClassifyBMI(t *testing.T) {
+	tests := []struct {
+		bmi      float64
+		standard string
+		level    string
+		severity int
+	}{
+		{18.4, BMIStandardWGOC, "underweight", 1},
+		{18.5, BMIStandardWGOC, "normal", 0},
+		{23.9, BMIStandardWGOC, "normal", 0},
+		{24, BMIStandardWGOC, "overweight", 1},
+		{27.9, BMIStandardWGOC, "overweight", 1},
+		{28, BMIStandardWGOC, "obese", 2},
+		{18.4, BMIStandardWHO, "underweight", 1},
+		{24.9, BMIStandardWHO, "normal", 0},
+		{25, BMIStandardWHO, "overweight", 1},
+		{29.9, BMIStandardWHO, "overweight", 1},
+		{30, BMIStandardWHO, "obese", 2},
+		{24, "unknown", "overweight", 1}, // 无效的标准使用默认标准
+	}
+	for _, tt := range tests {
+		s := ClassifyBMI(tt.bmi, tt.standard)
+		if s.Level != tt.level || s.Severity != tt.severity || s.Text == "" {
+			t.Errorf("ClassifyBMI(%v, %s) = %+v, want %s severity %d", tt.bmi, tt.standard, s, tt.level, tt.severity)
+		}
+	}
+	if s := ClassifyBMI(24, "unknown"); s.Standard != DefaultBMIStandard {
+		t.Errorf("unknown standard classified with %s, want %s", s.Standard, DefaultBMIStandard)
+	}
+}
+
+func TestBodyComposition(t *testing.T) {
+	tests := []struct {
+		name                      string
+		height, weight, waistline float64
+		bmi                       float64
+		level                     string
+		ratio                     float64
+		central                   string // "" 表示未计算
+	}{
+		{"体重与腰围", 170, 65, 80, 22.5, "normal", 0.47, "no"},
+		{"腰围身高比恰为0.5", 170, 80, 85, 27.7, "overweight", 0.5, "yes"},
+		{"只有体重", 160, 77, 0, 30.1, "obese", 0, ""},
+		{"只有腰围", 180, 0, 95, 0, "", 0.53, "yes"},
+	}
+	for _, tt := range tests {
+		m := BodyComposition(tt.height, tt.weight, tt.waistline, false, BMIStandardWGOC)
+		if m == nil {
+			t.Errorf("%s: nil metrics", tt.name)
+			continue
+		}
+		if m.BMI != tt.bmi {
+			t.Errorf("%s: BMI = %v, want %v", tt.name, m.BMI, tt.bmi)
+		}
+		if (m.BMIStatus == nil) != (tt.level == "") || (m.BMIStatus != nil && m.BMIStatus.Level != tt.level) {
+			t.Errorf("%s: BMI status = %+v, want %q", tt.name, m.BMIStatus, tt.level)
+		}
+		if m.WaistToHeight != tt.ratio {
+			t.Errorf("%s: waist-to-height = %v, want %v", tt.name, m.WaistToHeight, tt.ratio)
+		}
+		switch {
+		case tt.central == "" && m.CentralObesity != nil,
+			tt.central != "" && (m.CentralObesity == nil || *m.CentralObesity != (tt.central == "yes")):
+			t.Errorf("%s: central obesity = %v, want %s", tt.name, m.CentralObesity, tt.central)
+		}
+	}
+
+	for _, in := range [][3]float64{{0, 65, 80}, {170, 0, 0}} {
+		if m := BodyComposition(in[0], in[1], in[2], false, BMIStandardWGOC); m != nil {
+			t.Errorf("BodyComposition(%v) = %+v, want nil", in, m)
+		}
+	}
+	if m := BodyComposition(170, 65, 0, true, BMIStandardWGOC); !m.HeightFromHistory || m.Height != 170 {
+		t.Errorf("height from history not reported: %+v", m)
+	}
+}
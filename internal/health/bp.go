@@ -0,0 +1,126 @@
+package health
+
+// 血压分级指南
+const (
+	GuidelineChina2018  = "china_2018"
+	GuidelineACCAHA2017 = "acc_aha_2017"
+	GuidelineESCESH2018 = "esc_esh_2018"
+)
+
+// DefaultGuideline 未设置时使用的分级指南
+const DefaultGuideline = GuidelineChina2018
+
+// BPStatus 血压分级结果。Severity 用于界面配色和提醒：
+// 0 正常，1 偏高（正常高值/血压升高），2 起为高血压且数值越大越严重
+type BPStatus struct {
+	Guideline string `json:"guideline"`
+	Level     string `json:"level"`
+	Text      string `json:"text"`
+	Severity  int    `json:"severity"`
+}
+
+// bpLevel 分级中的一档，收缩压或舒张压任一达到阈值即归入该档，0 表示该项不参与判断
+type bpLevel struct {
+	level     string
+	text      string
+	severity  int
+	systolic  int
+	diastolic int
+}
+
+// bpGuideline 一套分级标准，levels 按严重程度从高到低排列，都未达到时为 base
+type bpGuideline struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	levels []bpLevel
+	base   bpLevel
+}
+
+// Guidelines 支持的血压分级指南
+var Guidelines = []bpGuideline{
+	{
+		ID:   GuidelineChina2018,
+		Name: "中国高血压防治指南（2018年修订版）",
+		levels: []bpLevel{
+			{"grade3", "3级高血压", 4, 180, 110},
+			{"grade2", "2级高血压", 3, 160, 100},
+			{"grade1", "1级高血压", 2, 140, 90},
+			{"high_normal", "正常高值", 1, 120, 80},
+		},
+		base: bpLevel{level: "normal", text: "正常"},
+	},
+	{
+		ID:   GuidelineACCAHA2017,
+		Name: "ACC/AHA 2017",
+		levels: []bpLevel{
+			{"crisis", "高血压危象", 4, 181, 121},
+			{"stage2", "2期高血压", 3, 140, 90},
+			{"stage1", "1期高血压", 2, 130, 80},
+			{"elevated", "血压升高", 1, 120, 0},
+		},
+		base: bpLevel{level: "normal", text: "正常"},
+	},
+	{
+		ID:   GuidelineESCESH2018,
+		Name: "ESC/ESH 2018",
+		levels: []bpLevel{
+			{"grade3", "3级高血压", 4, 180, 110},
+			{"grade2", "2级高血压", 3, 160, 100},
+			{"grade1", "1级高血压", 2, 140, 90},
+			{"high_normal", "正常高值", 1, 130, 85},
+			{"normal", "正常", 0, 120, 80},
+		},
+		base: bpLevel{level: "optimal", text: "理想"},
+	},
+}
+
+// lookupGuideline 按ID查找分级指南
+func lookupGuideline(id string) (bpGuideline, bool) {
+	for _, g := range Guidelines {
+		if g.ID == id {
+			return g, true
+		}
+	}
+	return bpGuideline{}, false
+}
+
+// ValidGuideline 判断是否为支持的分级指南
+func ValidGuideline(id string) bool {
+	_, ok := lookupGuideline(id)
+	return ok
+}
+
+// ClassifyBP 按指定指南对血压分级，指南无效时使用默认指南；未填写血压时返回 nil
+func ClassifyBP(systolic, diastolic int, guideline string) *BPStatus {
+	if systolic <= 0 && diastolic <= 0 {
+		return nil
+	}
+	g, ok := lookupGuideline(guideline)
+	if !ok {
+		g, _ = lookupGuideline(DefaultGuideline)
+	}
+
+	l := g.base
+	for _, candidate := range g.levels {
+		if (candidate.systolic > 0 && systolic >= candidate.systolic) ||
+			(candidate.diastolic > 0 && diastolic >= candidate.diastolic) {
+			l = candidate
+			break
+		}
+	}
+	return &BPStatus{Guideline: g.ID, Level: l.level, Text: l.text, Severity: l.severity}
+}
+
+// BPLevels 返回指南的全部分级，按严重程度从低到高排列，指南无效时使用默认指南
+func BPLevels(guideline string) []BPStatus {
+	g, ok := lookupGuideline(guideline)
+	if !ok {
+		g, _ = lookupGuideline(DefaultGuideline)
+	}
+	levels := []BPStatus{{Guideline: g.ID, Level: g.base.level, Text: g.base.text, Severity: g.base.severity}}
+	for i := len(g.levels) - 1; i >= 0; i-- {
+		l := g.levels[i]
+		levels = append(levels, BPStatus{Guideline: g.ID, Level: l.level, Text: l.text, Severity: l.severity})
+	}
+	return levels
+}
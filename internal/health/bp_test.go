@@ -0,0 +1,89 @@
+package health
+
+import "testing"
+
+func TestClassifyBP(t *testing.T) {
+	tests := []struct {
+		guideline           string
+		systolic, diastolic int
+		level               string
+		severity            int
+	}{
+		{GuidelineChina2018, 119, 79, "normal", 0},
+		{GuidelineChina2018, 120, 70, "high_normal", 1},
+		{GuidelineChina2018, 110, 80, "high_normal", 1},
+		{GuidelineChina2018, 139, 89, "high_normal", 1},
+		{GuidelineChina2018, 140, 70, "grade1", 2},
+		{GuidelineChina2018, 120, 90, "grade1", 2},
+		{GuidelineChina2018, 160, 99, "grade2", 3},
+		{GuidelineChina2018, 150, 100, "grade2", 3},
+		{GuidelineChina2018, 180, 80, "grade3", 4},
+		{GuidelineChina2018, 130, 110, "grade3", 4},
+
+		{GuidelineACCAHA2017, 119, 79, "normal", 0},
+		{GuidelineACCAHA2017, 125, 79, "elevated", 1},
+		{GuidelineACCAHA2017, 119, 80, "stage1", 2}, // 舒张压不参与"血压升高"
+		{GuidelineACCAHA2017, 130, 70, "stage1", 2},
+		{GuidelineACCAHA2017, 140, 85, "stage2", 3},
+		{GuidelineACCAHA2017, 120, 90, "stage2", 3},
+		{GuidelineACCAHA2017, 180, 120, "stage2", 3},
+		{GuidelineACCAHA2017, 181, 100, "crisis", 4},
+		{GuidelineACCAHA2017, 150, 121, "crisis", 4},
+
+		{GuidelineESCESH2018, 119, 79, "optimal", 0},
+		{GuidelineESCESH2018, 120, 70, "normal", 0},
+		{GuidelineESCESH2018, 129, 84, "normal", 0},
+		{GuidelineESCESH2018, 130, 70, "high_normal", 1},
+		{GuidelineESCESH2018, 120, 85, "high_normal", 1},
+		{GuidelineESCESH2018, 140, 80, "grade1", 2},
+		{GuidelineESCESH2018, 165, 95, "grade2", 3},
+		{GuidelineESCESH2018, 170, 110, "grade3", 4},
+
+		// 只填了一项
+		{GuidelineChina2018, 145, 0, "grade1", 2},
+		{GuidelineChina2018, 0, 95, "grade1", 2},
+		// 无效的指南使用默认指南
+		{"unknown", 140, 90, "grade1", 2},
+	}
+	for _, tt := range tests {
+		s := ClassifyBP(tt.systolic, tt.diastolic, tt.guideline)
+		if s == nil {
+			t.Errorf("%s %d/%d: nil status", tt.guideline, tt.systolic, tt.diastolic)
+			continue
+		}
+		if s.Level != tt.level || s.Severity != tt.severity || s.Text == "" {
+			t.Errorf("%s %d/%d = %+v, want level %s severity %d", tt.guideline, tt.systolic, tt.diastolic, s, tt.level, tt.severity)
+		}
+		if want := tt.guideline; ValidGuideline(want) && s.Guideline != want {
+			t.Errorf("%s %d/%d: guideline = %s", tt.guideline, tt.systolic, tt.diastolic, s.Guideline)
+		}
+	}
+
+	if s := ClassifyBP(0, 0, GuidelineChina2018); s != nil {
+		t.Errorf("no reading: status = %+v, want nil", s)
+	}
+	if s := ClassifyBP(140, 90, "unknown"); s.Guideline != DefaultGuideline {
+		t.Errorf("unknown guideline classified with %s, want %s", s.Guideline, DefaultGuideline)
+	}
+}
+
+func TestBPLevels(t *testing.T) {
+	for _, g := range Guidelines {
+		levels := BPLevels(g.ID)
+		if len(levels) != len(g.levels)+1 {
+			t.Errorf("%s: %d levels, want %d", g.ID, len(levels), len(g.levels)+1)
+			continue
+		}
+		if levels[0].Level != g.base.level {
+			t.Errorf("%s: first level = %s, want %s", g.ID, levels[0].Level, g.base.level)
+		}
+		for i := 1; i < len(levels); i++ {
+			if levels[i].Severity < levels[i-1].Severity {
+				t.Errorf("%s: levels not ordered by severity: %+v", g.ID, levels)
+			}
+		}
+	}
+	if levels := BPLevels("unknown"); levels[0].Guideline != DefaultGuideline {
+		t.Errorf("unknown guideline uses %s, want %s", levels[0].Guideline, DefaultGuideline)
+	}
+}
@@ -0,0 +1,144 @@
+// Package health 健康指标的计算与判定，供接口、统计和提醒共用同一套规则
+package health
+
+import (
+	"fmt"
+	"math"
+)
+
+// 血糖单位
+const (
+	UnitMmolL = "mmol/L"
+	UnitMgdL  = "mg/dL"
+)
+
+// mgdlPerMmol 葡萄糖 mg/dL 与 mmol/L 的换算系数
+const mgdlPerMmol = 18.0
+
+// 血糖测量场景
+const (
+	GlucoseFasting    = "fasting"
+	GlucoseBeforeMeal = "before_meal"
+	GlucoseAfterMeal  = "after_meal"
+	GlucoseBedtime    = "bedtime"
+	GlucoseRandom     = "random"
+)
+
+// GlucoseContexts 支持的血糖测量场景及名称
+var GlucoseContexts = []struct {
+	Context string `json:"context"`
+	Name    string `json:"name"`
+}{
+	{GlucoseFasting, "空腹"},
+	{GlucoseBeforeMeal, "餐前"},
+	{GlucoseAfterMeal, "餐后2小时"},
+	{GlucoseBedtime, "睡前"},
+	{GlucoseRandom, "随机"},
+}
+
+// GlucoseContextName 返回测量场景名称，不支持的场景返回空字符串
+func GlucoseContextName(context string) string {
+	for _, c := range GlucoseContexts {
+		if c.Context == context {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// ToMmol 将血糖值换算为 mmol/L，保留两位小数
+func ToMmol(value float64, unit string) (float64, error) {
+	switch unit {
+	case "", UnitMmolL:
+		return value, nil
+	case UnitMgdL:
+		return math.Round(value/mgdlPerMmol*100) / 100, nil
+	}
+	return 0, fmt.Errorf("血糖单位必须为 %s 或 %s", UnitMmolL, UnitMgdL)
+}
+
+// ToMgdL 将 mmol/L 换算为 mg/dL，取整
+func ToMgdL(mmol float64) float64 {
+	return math.Round(mmol * mgdlPerMmol)
+}
+
+// GlucoseRange 血糖控制目标范围（mmol/L）
+type GlucoseRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// GlucoseTargets 各测量场景的控制目标
+type GlucoseTargets map[string]GlucoseRange
+
+// DefaultGlucoseTargets 默认控制目标，参考《中国2型糖尿病防治指南（2020年版）》：
+// 空腹及餐前 4.4–7.0，非空腹 <10.0 mmol/L
+func DefaultGlucoseTargets() GlucoseTargets {
+	return GlucoseTargets{
+		GlucoseFasting:    {Min: 4.4, Max: 7.0},
+		GlucoseBeforeMeal: {Min: 4.4, Max: 7.0},
+		GlucoseAfterMeal:  {Min: 4.4, Max: 10.0},
+		GlucoseBedtime:    {Min: 4.4, Max: 10.0},
+		GlucoseRandom:     {Min: 4.4, Max: 10.0},
+	}
+}
+
+// Validate 校验控制目标，允许只设置部分场景
+func (t GlucoseTargets) Validate() error {
+	for context, r := range t {
+		name := GlucoseContextName(context)
+		if name == "" {
+			return fmt.Errorf("不支持的测量场景 %s", context)
+		}
+		if r.Min <= 0 || r.Max <= r.Min {
+			return fmt.Errorf("%s目标范围无效", name)
+		}
+	}
+	return nil
+}
+
+// Merge 返回以 override 覆盖 t 中对应场景后的控制目标
+func (t GlucoseTargets) Merge(override GlucoseTargets) GlucoseTargets {
+	merged := GlucoseTargets{}
+	for context, r := range t {
+		merged[context] = r
+	}
+	for context, r := range override {
+		merged[context] = r
+	}
+	return merged
+}
+
+// hypoglycemia 低血糖阈值（mmol/L），低于此值无论目标如何均判定为低血糖
+const hypoglycemia = 3.9
+
+// 血糖判定结果
+const (
+	GlucoseHypo   = "hypo"
+	GlucoseLow    = "low"
+	GlucoseNormal = "normal"
+	GlucoseHigh   = "high"
+)
+
+// GlucoseStatus 血糖判定结果
+type GlucoseStatus struct {
+	Level string `json:"level"`
+	Text  string `json:"text"`
+}
+
+// ClassifyGlucose 按测量场景的控制目标判定血糖，场景为空时按随机血糖判定
+func ClassifyGlucose(mmol float64, context string, targets GlucoseTargets) GlucoseStatus {
+	r, ok := targets[context]
+	if !ok {
+		r = targets[GlucoseRandom]
+	}
+	switch {
+	case mmol < hypoglycemia:
+		return GlucoseStatus{GlucoseHypo, "低血糖"}
+	case mmol < r.Min:
+		return GlucoseStatus{GlucoseLow, "偏低"}
+	case mmol > r.Max:
+		return GlucoseStatus{GlucoseHigh, "偏高"}
+	}
+	return GlucoseStatus{GlucoseNormal, "达标"}
+}
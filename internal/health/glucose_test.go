@@ -0,0 +1,90 @@
+package health
+
+import "testing"
+
+func TestToMmol(t *testing.T) {
+	tests := []struct {
+		value float64
+		unit  string
+		want  float64
+		ok    bool
+	}{
+		{5.6, UnitMmolL, 5.6, true},
+		{5.6, "", 5.6, true},
+		{100, UnitMgdL, 5.56, true},
+		{126, UnitMgdL, 7, true},
+		{100, "mg", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ToMmol(tt.value, tt.unit)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ToMmol(%v, %q) = %v, %v, want %v ok = %v", tt.value, tt.unit, got, err, tt.want, tt.ok)
+		}
+	}
+	if got := ToMgdL(7); got != 126 {
+		t.Errorf("ToMgdL(7) = %v, want 126", got)
+	}
+}
+
+func TestClassifyGlucose(t *testing.T) {
+	targets := DefaultGlucoseTargets()
+	tests := []struct {
+		mmol    float64
+		context string
+		level   string
+	}{
+		{3.8, GlucoseFasting, GlucoseHypo},
+		{3.8, GlucoseAfterMeal, GlucoseHypo},
+		{3.9, GlucoseFasting, GlucoseLow},
+		{4.3, GlucoseBeforeMeal, GlucoseLow},
+		{4.4, GlucoseFasting, GlucoseNormal},
+		{7.0, GlucoseFasting, GlucoseNormal},
+		{7.1, GlucoseFasting, GlucoseHigh},
+		{7.1, GlucoseAfterMeal, GlucoseNormal},
+		{10.0, GlucoseBedtime, GlucoseNormal},
+		{10.1, GlucoseAfterMeal, GlucoseHigh},
+		// 场景为空或不支持时按随机血糖判定
+		{9.0, "", GlucoseNormal},
+		{10.5, "snack", GlucoseHigh},
+	}
+	for _, tt := range tests {
+		s := ClassifyGlucose(tt.mmol, tt.context, targets)
+		if s.Level != tt.level || s.Text == "" {
+			t.Errorf("ClassifyGlucose(%v, %q) = %+v, want %s", tt.mmol, tt.context, s, tt.level)
+		}
+	}
+
+	// 自定义目标只覆盖指定场景，低血糖阈值不受目标影响
+	custom := targets.Merge(GlucoseTargets{GlucoseFasting: {Min: 3.0, Max: 6.1}})
+	if s := ClassifyGlucose(6.5, GlucoseFasting, custom); s.Level != GlucoseHigh {
+		t.Errorf("custom fasting 6.5 = %s, want %s", s.Level, GlucoseHigh)
+	}
+	if s := ClassifyGlucose(3.5, GlucoseFasting, custom); s.Level != GlucoseHypo {
+		t.Errorf("custom fasting 3.5 = %s, want %s", s.Level, GlucoseHypo)
+	}
+	if s := ClassifyGlucose(6.5, GlucoseBeforeMeal, custom); s.Level != GlucoseNormal {
+		t.Errorf("before meal 6.5 = %s, want %s", s.Level, GlucoseNormal)
+	}
+	if targets[GlucoseFasting].Max != 7.0 {
+		t.Error("Merge modified the original targets")
+	}
+}
+
+func TestGlucoseTargetsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets GlucoseTargets
+		ok      bool
+	}{
+		{"默认目标", DefaultGlucoseTargets(), true},
+		{"部分场景", GlucoseTargets{GlucoseFasting: {Min: 4, Max: 6}}, true},
+		{"不支持的场景", GlucoseTargets{"snack": {Min: 4, Max: 6}}, false},
+		{"下限为0", GlucoseTargets{GlucoseFasting: {Min: 0, Max: 6}}, false},
+		{"上限不大于下限", GlucoseTargets{GlucoseFasting: {Min: 6, Max: 6}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.targets.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
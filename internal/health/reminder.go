@@ -0,0 +1,57 @@
+package health
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultReminderWindow 测量提醒未指定检查时长时使用的分钟数
+const DefaultReminderWindow = 60
+
+// maxReminderWindow 检查时长的上限（分钟）
+const maxReminderWindow = 12 * 60
+
+// Reminder 每天的测量提醒：到 Time（用户时区 HH:MM）时，若之前 Window 分钟内没有血压记录则发送提醒
+type Reminder struct {
+	Time string `json:"time"`
+	// Window 检查的时长（分钟），为0时使用 DefaultReminderWindow
+	Window int `json:"window,omitempty"`
+}
+
+// WindowDuration 检查的时长
+func (r Reminder) WindowDuration() time.Duration {
+	if r.Window <= 0 {
+		return DefaultReminderWindow * time.Minute
+	}
+	return time.Duration(r.Window) * time.Minute
+}
+
+// Due 返回 now 之前（含）最近一次的提醒时间，按 now 所在时区计算
+func (r Reminder) Due(now time.Time) time.Time {
+	m, _ := clockMinutes(r.Time)
+	y, mo, d := now.Date()
+	due := time.Date(y, mo, d, m/60, m%60, 0, 0, now.Location())
+	if due.After(now) {
+		due = due.AddDate(0, 0, -1)
+	}
+	return due
+}
+
+// ValidateReminders 校验测量提醒：时间为 HH:MM 且不重复，检查时长不超过12小时
+func ValidateReminders(reminders []Reminder) error {
+	seen := map[string]bool{}
+	for _, r := range reminders {
+		if m, err := clockMinutes(r.Time); err != nil || m >= 24*60 || len(r.Time) != 5 {
+			return fmt.Errorf("提醒时间无效，应为 HH:MM")
+		}
+		if seen[r.Time] {
+			return fmt.Errorf("提醒时间 %s 重复", r.Time)
+		}
+		seen[r.Time] = true
+		// 0 表示未填写，使用 DefaultReminderWindow
+		if r.Window < 0 || r.Window > maxReminderWindow {
+			return fmt.Errorf("检查时长应在 1–%d 分钟之间，不填写时为 %d 分钟", maxReminderWindow, DefaultReminderWindow)
+		}
+	}
+	return nil
+}
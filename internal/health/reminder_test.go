@@ -0,0 +1,63 @@
+package health
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReminderDue(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		time, now, want string
+	}{
+		{"07:00", "2024-03-05 08:30", "2024-03-05 07:00"},
+		{"07:00", "2024-03-05 07:00", "2024-03-05 07:00"}, // 恰好到点
+		{"07:00", "2024-03-05 06:59", "2024-03-04 07:00"}, // 未到点时为前一天
+		{"21:00", "2024-03-01 00:30", "2024-02-29 21:00"}, // 跨月
+		{"00:00", "2024-03-05 00:00", "2024-03-05 00:00"},
+		{"23:59", "2024-03-05 23:58", "2024-03-04 23:59"},
+		{"07:00", "2024-03-10 08:00", "2024-03-10 07:00"}, // 夏令时开始当天
+	}
+	for _, tt := range tests {
+		now, _ := time.ParseInLocation("2006-01-02 15:04", tt.now, loc)
+		got := Reminder{Time: tt.time}.Due(now)
+		if got.Format("2006-01-02 15:04") != tt.want || got.After(now) {
+			t.Errorf("Reminder{%s}.Due(%s) = %v, want %s", tt.time, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestReminderWindowDuration(t *testing.T) {
+	if got := (Reminder{Time: "07:00"}).WindowDuration(); got != DefaultReminderWindow*time.Minute {
+		t.Errorf("default window = %v", got)
+	}
+	if got := (Reminder{Time: "07:00", Window: 90}).WindowDuration(); got != 90*time.Minute {
+		t.Errorf("window = %v, want 1h30m", got)
+	}
+}
+
+func TestValidateReminders(t *testing.T) {
+	tests := []struct {
+		name      string
+		reminders []Reminder
+		ok        bool
+	}{
+		{"空", nil, true},
+		{"早晚各一次", []Reminder{{Time: "07:00"}, {Time: "21:00", Window: 120}}, true},
+		{"最长检查时长", []Reminder{{Time: "07:00", Window: maxReminderWindow}}, true},
+		{"时间格式错误", []Reminder{{Time: "7:00"}}, false},
+		{"时间无效", []Reminder{{Time: "25:00"}}, false},
+		{"不允许 24:00", []Reminder{{Time: "24:00"}}, false},
+		{"时间重复", []Reminder{{Time: "07:00"}, {Time: "07:00", Window: 30}}, false},
+		{"检查时长为负", []Reminder{{Time: "07:00", Window: -1}}, false},
+		{"检查时长过长", []Reminder{{Time: "07:00", Window: maxReminderWindow + 1}}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateReminders(tt.reminders); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
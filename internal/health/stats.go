@@ -0,0 +1,43 @@
+package health
+
+import "math"
+
+// Summary 一组读数的统计值，保留一位小数
+type Summary struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	StdDev float64 `json:"stddev"` // 样本标准差，少于2个读数时为0
+}
+
+// Summarize 计算读数的均值、最值和标准差，没有读数时返回 nil
+func Summarize(values []float64) *Summary {
+	if len(values) == 0 {
+		return nil
+	}
+
+	s := &Summary{Count: len(values), Min: values[0], Max: values[0]}
+	var sum float64
+	for _, v := range values {
+		sum += v
+		s.Min = math.Min(s.Min, v)
+		s.Max = math.Max(s.Max, v)
+	}
+	mean := sum / float64(len(values))
+
+	if len(values) > 1 {
+		var sq float64
+		for _, v := range values {
+			sq += (v - mean) * (v - mean)
+		}
+		s.StdDev = round1(math.Sqrt(sq / float64(len(values)-1)))
+	}
+	s.Mean = round1(mean)
+	return s
+}
+
+// round1 保留一位小数
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,25 @@
+package health
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   Summary
+	}{
+		{[]float64{120}, Summary{Count: 1, Mean: 120, Min: 120, Max: 120}},
+		{[]float64{120, 140}, Summary{Count: 2, Mean: 130, Min: 120, Max: 140, StdDev: 14.1}},
+		{[]float64{120, 130, 140}, Summary{Count: 3, Mean: 130, Min: 120, Max: 140, StdDev: 10}},
+		{[]float64{135, 135, 135, 135}, Summary{Count: 4, Mean: 135, Min: 135, Max: 135}},
+		{[]float64{118, 125, 131, 142, 127}, Summary{Count: 5, Mean: 128.6, Min: 118, Max: 142, StdDev: 8.8}},
+	}
+	for _, tt := range tests {
+		got := Summarize(tt.values)
+		if got == nil || *got != tt.want {
+			t.Errorf("Summarize(%v) = %+v, want %+v", tt.values, got, tt.want)
+		}
+	}
+	if got := Summarize(nil); got != nil {
+		t.Errorf("Summarize(nil) = %+v, want nil", got)
+	}
+}
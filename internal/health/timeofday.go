@@ -0,0 +1,131 @@
+package health
+
+import (
+	"fmt"
+	"time"
+)
+
+// 早晨、晚间时段的名称，家庭血压分析依赖这两个时段
+const (
+	WindowMorning = "morning"
+	WindowEvening = "evening"
+)
+
+// 家庭血压：诊断阈值（mmHg）及去掉第1天后至少需要的天数
+const (
+	HomeSystolicThreshold  = 135
+	HomeDiastolicThreshold = 85
+	HomeMinDays            = 3
+)
+
+// TimeWindow 一天中的时段，Start/End 为 HH:MM（End 可为 24:00），Start 晚于 End 表示跨午夜
+type TimeWindow struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// DefaultTimeWindows 默认时段：起床后（05:00–10:00）与睡前（18:00–24:00）
+func DefaultTimeWindows() []TimeWindow {
+	return []TimeWindow{
+		{Name: WindowMorning, Label: "早晨", Start: "05:00", End: "10:00"},
+		{Name: WindowEvening, Label: "晚间", Start: "18:00", End: "24:00"},
+	}
+}
+
+// clockMinutes 将 HH:MM 转换为当天的分钟数，允许 24:00
+func clockMinutes(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains 判断 t（已转换为用户时区）是否落在时段内，区间为 [Start, End)
+func (w TimeWindow) Contains(t time.Time) bool {
+	start, _ := clockMinutes(w.Start)
+	end, _ := clockMinutes(w.End)
+	m := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// Date 返回读数所属的日期：跨午夜时段中午夜之后的读数归入前一天
+func (w TimeWindow) Date(t time.Time) string {
+	start, _ := clockMinutes(w.Start)
+	end, _ := clockMinutes(w.End)
+	if start > end && t.Hour()*60+t.Minute() < end {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t.Format("2006-01-02")
+}
+
+// spans 时段在一天中覆盖的分钟区间 [start, end)，跨午夜的时段拆为两段
+func (w TimeWindow) spans() [][2]int {
+	start, _ := clockMinutes(w.Start)
+	end, _ := clockMinutes(w.End)
+	if start <= end {
+		return [][2]int{{start, end}}
+	}
+	return [][2]int{{start, 24 * 60}, {0, end}}
+}
+
+// overlaps 判断两个时段是否有重叠的部分
+func (w TimeWindow) overlaps(other TimeWindow) bool {
+	for _, a := range w.spans() {
+		for _, b := range other.spans() {
+			if a[0] < b[1] && b[0] < a[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateTimeWindows 校验时段设置：名称不能重复，时段之间不能重叠，必须包含早晨和晚间时段，时间格式为 HH:MM
+func ValidateTimeWindows(windows []TimeWindow) error {
+	seen := map[string]bool{}
+	for _, w := range windows {
+		if w.Name == "" {
+			return fmt.Errorf("时段名称不能为空")
+		}
+		if seen[w.Name] {
+			return fmt.Errorf("时段 %s 重复", w.Name)
+		}
+		seen[w.Name] = true
+
+		start, err1 := clockMinutes(w.Start)
+		end, err2 := clockMinutes(w.End)
+		if err1 != nil || err2 != nil || start == end {
+			return fmt.Errorf("时段 %s 的起止时间无效，应为 HH:MM", w.Name)
+		}
+	}
+	for i, a := range windows {
+		for _, b := range windows[i+1:] {
+			if a.overlaps(b) {
+				return fmt.Errorf("时段 %s 与 %s 重叠", a.Name, b.Name)
+			}
+		}
+	}
+	if !seen[WindowMorning] || !seen[WindowEvening] {
+		return fmt.Errorf("必须包含 %s 和 %s 时段", WindowMorning, WindowEvening)
+	}
+	return nil
+}
+
+// MatchWindow 返回 t 所在的时段，不在任何时段内时 ok 为 false
+func MatchWindow(windows []TimeWindow, t time.Time) (w TimeWindow, ok bool) {
+	for _, w := range windows {
+		if w.Contains(t) {
+			return w, true
+		}
+	}
+	return TimeWindow{}, false
+}
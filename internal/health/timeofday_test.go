@@ -0,0 +1,105 @@
+package health
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeWindowContains(t *testing.T) {
+	day := TimeWindow{Name: "day", Start: "05:00", End: "10:00"}
+	late := TimeWindow{Name: "late", Start: "18:00", End: "24:00"}
+	night := TimeWindow{Name: "night", Start: "22:00", End: "02:00"}
+
+	tests := []struct {
+		w     TimeWindow
+		clock string
+		want  bool
+	}{
+		{day, "04:59", false},
+		{day, "05:00", true},
+		{day, "09:59", true},
+		{day, "10:00", false},
+		{late, "18:00", true},
+		{late, "23:59", true},
+		{late, "00:00", false},
+		{night, "21:59", false},
+		{night, "22:00", true},
+		{night, "23:30", true},
+		{night, "00:00", true},
+		{night, "01:59", true},
+		{night, "02:00", false},
+		{night, "12:00", false},
+	}
+	for _, tt := range tests {
+		at, _ := time.Parse("2006-01-02 15:04", "2024-03-05 "+tt.clock)
+		if got := tt.w.Contains(at); got != tt.want {
+			t.Errorf("%s.Contains(%s) = %v, want %v", tt.w.Name, tt.clock, got, tt.want)
+		}
+	}
+}
+
+func TestTimeWindowDate(t *testing.T) {
+	day := TimeWindow{Name: "day", Start: "05:00", End: "10:00"}
+	night := TimeWindow{Name: "night", Start: "22:00", End: "02:00"}
+
+	tests := []struct {
+		w    TimeWindow
+		at   string
+		want string
+	}{
+		{day, "2024-03-05 07:30", "2024-03-05"},
+		{night, "2024-03-05 23:00", "2024-03-05"},
+		{night, "2024-03-06 01:30", "2024-03-05"}, // 午夜之后归入前一天
+		{night, "2024-03-01 00:10", "2024-02-29"}, // 跨月
+		{night, "2024-03-06 02:00", "2024-03-06"},
+	}
+	for _, tt := range tests {
+		at, _ := time.Parse("2006-01-02 15:04", tt.at)
+		if got := tt.w.Date(at); got != tt.want {
+			t.Errorf("%s.Date(%s) = %s, want %s", tt.w.Name, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTimeWindows(t *testing.T) {
+	base := DefaultTimeWindows()
+	with := func(extra ...TimeWindow) []TimeWindow {
+		return append(append([]TimeWindow{}, base...), extra...)
+	}
+
+	tests := []struct {
+		name    string
+		windows []TimeWindow
+		ok      bool
+	}{
+		{"默认时段", base, true},
+		{"增加不重叠的时段", with(TimeWindow{Name: "noon", Start: "11:00", End: "13:00"}), true},
+		{"首尾相接", with(TimeWindow{Name: "forenoon", Start: "10:00", End: "12:00"}), true},
+		{"跨午夜不重叠", with(TimeWindow{Name: "night", Start: "00:00", End: "04:00"}), true},
+		{"缺少晚间时段", base[:1], false},
+		{"名称为空", with(TimeWindow{Start: "11:00", End: "13:00"}), false},
+		{"名称重复", with(TimeWindow{Name: WindowMorning, Start: "11:00", End: "13:00"}), false},
+		{"时间格式错误", with(TimeWindow{Name: "noon", Start: "11", End: "13:00"}), false},
+		{"起止相同", with(TimeWindow{Name: "noon", Start: "12:00", End: "12:00"}), false},
+		{"与早晨重叠", with(TimeWindow{Name: "noon", Start: "09:30", End: "13:00"}), false},
+		{"包含早晨", with(TimeWindow{Name: "day", Start: "04:00", End: "11:00"}), false},
+		{"跨午夜与晚间重叠", with(TimeWindow{Name: "night", Start: "23:00", End: "04:00"}), false},
+		{"跨午夜与早晨重叠", with(TimeWindow{Name: "night", Start: "00:00", End: "06:00"}), false},
+	}
+	for _, tt := range tests {
+		if err := ValidateTimeWindows(tt.windows); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestMatchWindow(t *testing.T) {
+	windows := DefaultTimeWindows()
+	for clock, want := range map[string]string{"06:00": WindowMorning, "20:00": WindowEvening, "12:00": ""} {
+		at, _ := time.Parse("2006-01-02 15:04", "2024-03-05 "+clock)
+		w, ok := MatchWindow(windows, at)
+		if ok != (want != "") || w.Name != want {
+			t.Errorf("MatchWindow(%s) = %q, %v, want %q", clock, w.Name, ok, want)
+		}
+	}
+}
@@ -12,7 +12,7 @@ import (
 )
 
 // AuthRequired 验证用户是否登录
-func AuthRequired() gin.HandlerFunc {
+func AuthRequired(store database.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
 		userID := session.Get("user_id")
@@ -23,7 +23,7 @@ func AuthRequired() gin.HandlerFunc {
 		}
 
 		// 核心：后端自动退出逻辑检查
-		idleTimeoutStr, _ := database.GetSetting("idle_timeout")
+		idleTimeoutStr, _ := store.GetSetting("idle_timeout")
 		idleTimeout, _ := strconv.Atoi(idleTimeoutStr)
 
 		if idleTimeout > 0 {
@@ -13,17 +13,18 @@ type User struct {
 
 // BloodPressure 健康记录模型（包含血压和身高体重）
 type BloodPressure struct {
-	ID         int64     `json:"id"`
-	UserID     int64     `json:"user_id"`
-	Systolic   int       `json:"systolic"`   // 收缩压 (mmHg)
-	Diastolic  int       `json:"diastolic"`  // 舒张压 (mmHg)
-	HeartRate  int       `json:"heart_rate"` // 心率 (次/分)
-	Height     float64   `json:"height"`     // 身高 (cm)
-	Weight     float64   `json:"weight"`     // 体重 (kg)
-	Waistline  float64   `json:"waistline"`  // 腰围 (cm)
-	RecordTime time.Time `json:"record_time"`
-	Notes      string    `json:"notes"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Systolic   int        `json:"systolic"`   // 收缩压 (mmHg)
+	Diastolic  int        `json:"diastolic"`  // 舒张压 (mmHg)
+	HeartRate  int        `json:"heart_rate"` // 心率 (次/分)
+	Height     float64    `json:"height"`     // 身高 (cm)
+	Weight     float64    `json:"weight"`     // 体重 (kg)
+	Waistline  float64    `json:"waistline"`  // 腰围 (cm)
+	RecordTime time.Time  `json:"record_time"`
+	Notes      string     `json:"notes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
 }
 
 // LoginRequest 登录请求
@@ -45,13 +46,90 @@ type ChangePasswordRequest struct {
 
 // CreateBPRequest 创建健康记录请求
 type CreateBPRequest struct {
-	Systolic  int     `json:"systolic"`   // 收缩压（可选）
-	Diastolic int     `json:"diastolic"`  // 舒张压（可选）
-	HeartRate int     `json:"heart_rate"` // 心率（可选）
-	Height    float64 `json:"height"`     // 身高（可选）
-	Weight    float64 `json:"weight"`     // 体重（可选）
-	Waistline float64 `json:"waistline"`  // 腰围（可选）
-	Notes     string  `json:"notes"`      // 备注
+	Systolic   int     `json:"systolic"`    // 收缩压（可选）
+	Diastolic  int     `json:"diastolic"`   // 舒张压（可选）
+	HeartRate  int     `json:"heart_rate"`  // 心率（可选）
+	Height     float64 `json:"height"`      // 身高（可选）
+	Weight     float64 `json:"weight"`      // 体重（可选）
+	Waistline  float64 `json:"waistline"`   // 腰围（可选）
+	Notes      string  `json:"notes"`       // 备注
+	RecordTime string  `json:"record_time"` // 测量时间（可选，RFC 3339 或用户时区的 "2006-01-02 15:04"）
+}
+
+// UpdateBPRequest 修改健康记录请求，只更新提供的字段
+type UpdateBPRequest struct {
+	Systolic   *int     `json:"systolic"`
+	Diastolic  *int     `json:"diastolic"`
+	HeartRate  *int     `json:"heart_rate"`
+	Height     *float64 `json:"height"`
+	Weight     *float64 `json:"weight"`
+	Waistline  *float64 `json:"waistline"`
+	Notes      *string  `json:"notes"`
+	RecordTime *string  `json:"record_time"` // 格式同 CreateBPRequest
+}
+
+// CreateMeasurementRequest 创建通用测量记录请求
+type CreateMeasurementRequest struct {
+	Type       string             `json:"type" binding:"required"`   // 测量类型，见 GET /api/measurements/types
+	Values     map[string]float64 `json:"values" binding:"required"` // 单值指标为 {"value": 36.8}
+	Unit       string             `json:"unit"`                      // 单位（可选，须与类型一致）
+	Context    string             `json:"context"`                   // 测量场景（可选）
+	Notes      string             `json:"notes"`                     // 备注
+	RecordTime string             `json:"record_time"`               // 测量时间（可选，格式同 CreateBPRequest）
+}
+
+// UpdateMeasurementRequest 修改通用测量记录请求，只更新提供的字段，Values 提供时整体替换
+type UpdateMeasurementRequest struct {
+	Values     map[string]float64 `json:"values"`
+	Context    *string            `json:"context"`
+	Notes      *string            `json:"notes"`
+	RecordTime *string            `json:"record_time"`
+}
+
+// CreateGlucoseRequest 记录血糖请求
+type CreateGlucoseRequest struct {
+	Value      float64 `json:"value"`       // 血糖值
+	Unit       string  `json:"unit"`        // mmol/L（默认）或 mg/dL
+	Context    string  `json:"context"`     // fasting/before_meal/after_meal/bedtime/random（可选）
+	Notes      string  `json:"notes"`       // 备注
+	RecordTime string  `json:"record_time"` // 测量时间（可选，格式同 CreateBPRequest）
+}
+
+// UpdateGlucoseRequest 修改血糖记录请求，只更新提供的字段，Unit 只作用于本次提交的 Value
+type UpdateGlucoseRequest struct {
+	Value      *float64 `json:"value"`
+	Unit       string   `json:"unit"`
+	Context    *string  `json:"context"`
+	Notes      *string  `json:"notes"`
+	RecordTime *string  `json:"record_time"`
+}
+
+// MedicationRequest 添加药物请求
+type MedicationRequest struct {
+	Name     string   `json:"name"`     // 药名
+	Dose     string   `json:"dose"`     // 每次剂量，如 5mg
+	Schedule string   `json:"schedule"` // 服药频次说明，如 每日一次
+	Times    []string `json:"times"`    // 计划服药时间 HH:MM（可选）
+	Notes    string   `json:"notes"`
+}
+
+// UpdateMedicationRequest 修改药物请求，只更新提供的字段；Active 为 false 表示停用
+type UpdateMedicationRequest struct {
+	Name     *string   `json:"name"`
+	Dose     *string   `json:"dose"`
+	Schedule *string   `json:"schedule"`
+	Times    *[]string `json:"times"`
+	Notes    *string   `json:"notes"`
+	Active   *bool     `json:"active"`
+}
+
+// CreateIntakeRequest 记录服药请求，MedicationID 为0时需填写药名（临时用药）
+type CreateIntakeRequest struct {
+	MedicationID int64  `json:"medication_id"`
+	Name         string `json:"name"`
+	Dose         string `json:"dose"`     // 为空时使用药物列表中的剂量
+	TakenAt      string `json:"taken_at"` // 服药时间（可选，格式同 CreateBPRequest 的 record_time）
+	Notes        string `json:"notes"`
 }
 
 // BPQueryRequest 血压查询请求
@@ -68,4 +146,6 @@ type DBConfigRequest struct {
 	User     string `json:"user"`
 	Password string `json:"password"`
 	DBName   string `json:"dbname"`
+	CopyData bool   `json:"copy_data"` // 切换时将当前数据复制到新数据库
+	DryRun   bool   `json:"dry_run"`   // 仅统计数据条数，不复制也不切换
 }
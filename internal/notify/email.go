@@ -0,0 +1,378 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"health-manager/internal/database"
+)
+
+// smtpSettingKey 邮件服务器配置在全局设置中的键名
+const smtpSettingKey = "smtp"
+
+// smtpPasswordKey 邮件服务器密码单独保存在凭据设置中，不随逻辑备份导出
+const smtpPasswordKey = database.SecretSettingPrefix + "smtp_password"
+
+// EmailLanguageKey 用户邮件语言在用户设置中的键名，未设置时使用服务器配置的默认语言
+const EmailLanguageKey = "email_language"
+
+// 邮件语言
+const (
+	LanguageZh = "zh"
+	LanguageEn = "en"
+)
+
+// 连接加密方式
+const (
+	SecurityNone     = "none"
+	SecurityStartTLS = "starttls"
+	SecurityTLS      = "tls" // 直接以 TLS 连接，通常为 465 端口
+)
+
+const smtpTimeout = 10 * time.Second
+
+// SMTPConfig 邮件服务器配置
+type SMTPConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Security string `json:"security"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from"`
+	Language string `json:"language"` // 默认邮件语言
+}
+
+// Validate 校验配置，未启用时只校验填写了的字段
+func (c SMTPConfig) Validate() error {
+	if c.Security != SecurityNone && c.Security != SecurityStartTLS && c.Security != SecurityTLS {
+		return fmt.Errorf("加密方式必须为 none、starttls 或 tls")
+	}
+	if !ValidLanguage(c.Language) {
+		return fmt.Errorf("邮件语言必须为 zh 或 en")
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("端口无效")
+	}
+	if c.From != "" {
+		if _, err := mail.ParseAddress(c.From); err != nil {
+			return fmt.Errorf("发件人地址无效")
+		}
+	}
+	if c.Enabled && (c.Host == "" || c.Port == 0 || c.From == "") {
+		return fmt.Errorf("启用邮件通知需要填写服务器、端口和发件人")
+	}
+	return nil
+}
+
+// ValidLanguage 判断是否为支持的邮件语言
+func ValidLanguage(lang string) bool {
+	return lang == LanguageZh || lang == LanguageEn
+}
+
+// DefaultSMTPConfig 默认配置：关闭，STARTTLS，587 端口，中文
+func DefaultSMTPConfig() SMTPConfig {
+	return SMTPConfig{Port: 587, Security: SecurityStartTLS, Language: LanguageZh}
+}
+
+// emailTemplate 一种事件的邮件模板，subject 与 body 均为 text/template
+type emailTemplate struct {
+	subject string
+	body    string
+}
+
+// emailTemplates 按语言、事件类型的邮件模板，没有模板的事件不发送邮件
+var emailTemplates = map[string]map[string]emailTemplate{
+	LanguageZh: {
+		EventAlertTriggered: {
+			subject: "健康提醒：{{metric .Data.Metric}} {{num .Data.Value}}",
+			body: `{{.Username}}，您好：
+
+{{time .Data.TriggeredAt}} 的记录触发了提醒：
+{{.Data.Message}}
+
+请留意身体状况，必要时及时就医。
+—— 健康管理系统
+`,
+		},
+		EventReminderDue: {
+			subject: "测量提醒：{{.Data.Time}}",
+			body: `{{.Username}}，您好：
+
+{{time .Data.Start}} 至 {{time .Data.End}} 之间还没有血压记录，请记得测量并记录血压。
+—— 健康管理系统
+`,
+		},
+		EventEmailTest: {
+			subject: "测试邮件",
+			body: `这是一封来自健康管理系统的测试邮件，收到说明邮件通知配置正确。
+—— 健康管理系统
+`,
+		},
+	},
+	LanguageEn: {
+		EventAlertTriggered: {
+			subject: "Health alert: {{metric .Data.Metric}} {{num .Data.Value}}",
+			body: `Hello {{.Username}},
+
+The reading recorded at {{time .Data.TriggeredAt}} triggered an alert:
+{{metric .Data.Metric}} {{num .Data.Value}} (rule: {{op .Data.Operator}} {{num .Data.Threshold}})
+
+Please keep an eye on your health and seek medical advice if needed.
+-- Health Manager
+`,
+		},
+		EventReminderDue: {
+			subject: "Measurement reminder: {{.Data.Time}}",
+			body: `Hello {{.Username}},
+
+No blood pressure reading was logged between {{time .Data.Start}} and {{time .Data.End}}.
+Please remember to measure and record your blood pressure.
+-- Health Manager
+`,
+		},
+		EventEmailTest: {
+			subject: "Test email",
+			body: `This is a test email from Health Manager. Email notifications are configured correctly.
+-- Health Manager
+`,
+		},
+	},
+}
+
+// metricNames 提醒指标在邮件中的名称
+var metricNames = map[string]map[string]string{
+	LanguageZh: {"systolic": "收缩压", "diastolic": "舒张压", "heart_rate": "心率", "weight": "体重", "weight_change": "体重变化"},
+	LanguageEn: {"systolic": "Systolic BP", "diastolic": "Diastolic BP", "heart_rate": "Heart rate", "weight": "Weight", "weight_change": "Weight change"},
+}
+
+// templateFuncs 模板中可用的格式化函数
+func templateFuncs(lang string) template.FuncMap {
+	return template.FuncMap{
+		"metric": func(id string) string {
+			if name, ok := metricNames[lang][id]; ok {
+				return name
+			}
+			return id
+		},
+		"num":  func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) },
+		"op":   func(op string) string { return strings.NewReplacer(">=", "≥", "<=", "≤").Replace(op) },
+		"time": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	}
+}
+
+// emailData 渲染模板的数据
+type emailData struct {
+	Username string
+	Event
+}
+
+// renderEmail 渲染邮件标题和正文，没有该事件的模板时 ok 为 false
+func renderEmail(lang string, data emailData) (subject, body string, ok bool, err error) {
+	tpl, ok := emailTemplates[lang][data.Type]
+	if !ok {
+		return "", "", false, nil
+	}
+	var out [2]bytes.Buffer
+	for i, text := range []string{tpl.subject, tpl.body} {
+		t, err := template.New(data.Type).Funcs(templateFuncs(lang)).Parse(text)
+		if err != nil {
+			return "", "", true, err
+		}
+		if err := t.Execute(&out[i], data); err != nil {
+			return "", "", true, err
+		}
+	}
+	return out[0].String(), out[1].String(), true, nil
+}
+
+// Mailer 通过 SMTP 将提醒等事件发送到用户邮箱
+type Mailer struct {
+	db *database.Manager
+}
+
+// NewMailer 创建邮件通知渠道
+func NewMailer(db *database.Manager) *Mailer {
+	return &Mailer{db: db}
+}
+
+// LoadConfig 从全局设置读取配置，未设置时返回默认配置
+func (m *Mailer) LoadConfig() (SMTPConfig, error) {
+	cfg := DefaultSMTPConfig()
+	value, err := m.db.GetSetting(smtpSettingKey)
+	if err != nil || value == "" {
+		return cfg, err
+	}
+	if err := json.Unmarshal([]byte(value), &cfg); err != nil {
+		return DefaultSMTPConfig(), err
+	}
+	cfg.Password = ""
+	if cfg.Username != "" {
+		cfg.Password, err = m.db.GetSetting(smtpPasswordKey)
+	}
+	return cfg, err
+}
+
+// store 保存配置，密码写入凭据设置，配置中不含密码
+func (m *Mailer) store(cfg SMTPConfig) error {
+	if err := m.db.SetSetting(smtpPasswordKey, cfg.Password); err != nil {
+		return err
+	}
+	cfg.Password = ""
+	data, _ := json.Marshal(cfg)
+	return m.db.SetSetting(smtpSettingKey, string(data))
+}
+
+// savedPassword 服务器、端口和用户名都与已保存的配置相同时返回已保存的密码，
+// 避免把密码发给另一台服务器或用于另一个账号
+func (m *Mailer) savedPassword(cfg SMTPConfig) string {
+	old, err := m.LoadConfig()
+	if err != nil || old.Host != cfg.Host || old.Port != cfg.Port || old.Username != cfg.Username {
+		return ""
+	}
+	return old.Password
+}
+
+// SaveConfig 校验并保存配置。未填写密码时，服务器、端口和用户名都未改变才沿用已保存的密码，否则清除密码
+func (m *Mailer) SaveConfig(cfg SMTPConfig) error {
+	if cfg.Username == "" {
+		cfg.Password = ""
+	} else if cfg.Password == "" {
+		cfg.Password = m.savedPassword(cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return m.store(cfg)
+}
+
+// userLanguage 用户的邮件语言，未设置时使用配置的默认语言
+func (m *Mailer) userLanguage(userID int64, cfg SMTPConfig) string {
+	if lang, _ := m.db.GetUserSetting(userID, EmailLanguageKey); ValidLanguage(lang) {
+		return lang
+	}
+	return cfg.Language
+}
+
+// Name 渠道名称
+func (m *Mailer) Name() string { return "email" }
+
+// Send 将事件发送到所属用户的邮箱。未启用、用户未填写邮箱或该事件没有邮件模板时不发送
+func (m *Mailer) Send(e Event) error {
+	cfg, err := m.LoadConfig()
+	if err != nil || !cfg.Enabled || e.UserID == 0 {
+		return err
+	}
+	user, err := m.db.GetUserByID(e.UserID)
+	if err != nil || user.Email == "" {
+		return nil
+	}
+
+	subject, body, ok, err := renderEmail(m.userLanguage(e.UserID, cfg), emailData{Username: user.Username, Event: e})
+	if err != nil || !ok {
+		return err
+	}
+	return sendMail(cfg, user.Email, subject, body)
+}
+
+// Test 按给定配置立即发送一封测试邮件，用于保存前检查配置
+func (m *Mailer) Test(cfg SMTPConfig, to string) error {
+	if cfg.Password == "" && cfg.Username != "" {
+		cfg.Password = m.savedPassword(cfg)
+	}
+	if cfg.Host == "" || cfg.Port == 0 || cfg.From == "" {
+		return fmt.Errorf("请填写服务器、端口和发件人")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	subject, body, _, err := renderEmail(cfg.Language, emailData{Event: Event{Type: EventEmailTest, Time: time.Now()}})
+	if err != nil {
+		return err
+	}
+	return sendMail(cfg, to, subject, body)
+}
+
+// sendMail 连接邮件服务器发送一封纯文本邮件
+func sendMail(cfg SMTPConfig, to, subject, body string) error {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return fmt.Errorf("发件人地址无效")
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("收件人地址无效")
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+
+	var conn net.Conn
+	if cfg.Security == SecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(2 * smtpTimeout))
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if cfg.Security == SecurityStartTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n",
+		from.String(), rcpt.String(), mime.BEncoding.Encode("UTF-8", subject), time.Now().Format(time.RFC1123Z))
+	if _, err := w.Write([]byte(header)); err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,344 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"health-manager/internal/config"
+	"health-manager/internal/database"
+)
+
+// testEventData 各事件的示例数据，与实际发布事件时的数据类型一致
+func testEventData(eventType string) interface{} {
+	at := time.Date(2024, 3, 5, 7, 30, 0, 0, time.UTC)
+	switch eventType {
+	case EventAlertTriggered:
+		return database.Alert{
+			ID:          1,
+			UserID:      1,
+			Metric:      "systolic",
+			Operator:    ">=",
+			Threshold:   160,
+			Value:       172.5,
+			Message:     "收缩压 172.5 mmHg，达到提醒条件 ≥ 160 mmHg",
+			TriggeredAt: at,
+		}
+	case EventReminderDue:
+		// 与 reminder.Due 相同的字段（reminder 包依赖本包，不能直接引用）
+		return struct {
+			Time  string
+			Start time.Time
+			End   time.Time
+		}{"08:00", at.Add(-time.Hour), at}
+	}
+	return nil
+}
+
+func TestRenderEmailTemplates(t *testing.T) {
+	for lang, templates := range emailTemplates {
+		for eventType := range templates {
+			t.Run(lang+"/"+eventType, func(t *testing.T) {
+				data := emailData{Username: "alice", Event: Event{Type: eventType, UserID: 1, Data: testEventData(eventType)}}
+				subject, body, ok, err := renderEmail(lang, data)
+				if err != nil || !ok {
+					t.Fatalf("renderEmail: ok = %v, err = %v", ok, err)
+				}
+				if subject == "" || body == "" {
+					t.Fatalf("empty subject %q or body %q", subject, body)
+				}
+				if strings.Contains(subject+body, "<no value>") {
+					t.Errorf("missing template field:\n%s\n%s", subject, body)
+				}
+				if eventType != EventEmailTest && !strings.Contains(body, "alice") {
+					t.Errorf("body does not contain username:\n%s", body)
+				}
+			})
+		}
+	}
+}
+
+func TestRenderEmailLanguages(t *testing.T) {
+	// 两种语言的模板覆盖相同的事件
+	for eventType := range emailTemplates[LanguageZh] {
+		if _, ok := emailTemplates[LanguageEn][eventType]; !ok {
+			t.Errorf("%s has no %s template", eventType, LanguageEn)
+		}
+	}
+	for eventType := range emailTemplates[LanguageEn] {
+		if _, ok := emailTemplates[LanguageZh][eventType]; !ok {
+			t.Errorf("%s has no %s template", eventType, LanguageZh)
+		}
+	}
+}
+
+func TestRenderEmailFormatting(t *testing.T) {
+	data := emailData{Username: "alice", Event: Event{Type: EventAlertTriggered, Data: testEventData(EventAlertTriggered)}}
+
+	subject, body, _, err := renderEmail(LanguageEn, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Health alert: Systolic BP 172.5"; subject != want {
+		t.Errorf("subject = %q, want %q", subject, want)
+	}
+	for _, want := range []string{"2024-03-05 07:30", "(rule: ≥ 160)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, body)
+		}
+	}
+
+	subject, _, _, err = renderEmail(LanguageZh, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "健康提醒：收缩压 172.5"; subject != want {
+		t.Errorf("subject = %q, want %q", subject, want)
+	}
+}
+
+func TestRenderEmailWithoutTemplate(t *testing.T) {
+	for _, eventType := range []string{EventRecordCreated, EventRecordDeleted, EventUserCreated, EventWebhookTest} {
+		if _, _, ok, err := renderEmail(LanguageZh, emailData{Event: Event{Type: eventType}}); ok || err != nil {
+			t.Errorf("%s: ok = %v, err = %v, want no template", eventType, ok, err)
+		}
+	}
+}
+
+// smtpMessage 模拟邮件服务器收到的一封邮件
+type smtpMessage struct {
+	from, to string
+	data     []byte
+}
+
+// fakeSMTP 在本地端口上接收一封邮件的最简 SMTP 服务器，不支持 STARTTLS 和认证
+func fakeSMTP(t *testing.T) (port int, received <-chan smtpMessage) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tc := textproto.NewConn(conn)
+		var msg smtpMessage
+		tc.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tc.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				tc.PrintfLine("250-localhost")
+				tc.PrintfLine("250 8BITMIME")
+			case "MAIL":
+				msg.from = line
+				tc.PrintfLine("250 OK")
+			case "RCPT":
+				msg.to = line
+				tc.PrintfLine("250 OK")
+			case "DATA":
+				tc.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				if msg.data, err = tc.ReadDotBytes(); err != nil {
+					return
+				}
+				tc.PrintfLine("250 OK")
+			case "QUIT":
+				tc.PrintfLine("221 Bye")
+				ch <- msg
+				return
+			default:
+				tc.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, ch
+}
+
+func TestSendMail(t *testing.T) {
+	port, received := fakeSMTP(t)
+	cfg := SMTPConfig{Enabled: true, Host: "127.0.0.1", Port: port, Security: SecurityNone, From: "Health <health@example.com>", Language: LanguageZh}
+
+	subject := "健康提醒：收缩压 172.5"
+	// 含非ASCII字符、"=" 和超过76字符的行，需要 quoted-printable 编码和软换行
+	body := "alice，您好：\n\n收缩压 172.5 mmHg，达到提醒条件 ≥ 160 mmHg\na=b\n" + strings.Repeat("长", 40) + "\n"
+	if err := sendMail(cfg, "alice@example.com", subject, body); err != nil {
+		t.Fatalf("sendMail: %v", err)
+	}
+
+	var msg smtpMessage
+	select {
+	case msg = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	if msg.from != "MAIL FROM:<health@example.com> BODY=8BITMIME" && msg.from != "MAIL FROM:<health@example.com>" {
+		t.Errorf("MAIL = %q", msg.from)
+	}
+	if msg.to != "RCPT TO:<alice@example.com>" {
+		t.Errorf("RCPT = %q", msg.to)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(string(msg.data)))
+	if err != nil {
+		t.Fatalf("invalid message: %v\n%s", err, msg.data)
+	}
+	if got, want := m.Header.Get("Subject"), mime.BEncoding.Encode("UTF-8", subject); got != want {
+		t.Errorf("raw Subject = %q, want %q", got, want)
+	}
+	if got, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject")); err != nil || got != subject {
+		t.Errorf("Subject = %q (%v), want %q", got, err, subject)
+	}
+	if got := m.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q", got)
+	}
+	if got := m.Header.Get("Content-Type"); got != "text/plain; charset=UTF-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := m.Header.Get("To"); got != "<alice@example.com>" {
+		t.Errorf("To = %q", got)
+	}
+
+	// ReadDotBytes 已将 CRLF 换行转换为 LF
+	raw, _ := io.ReadAll(m.Body)
+	for _, line := range strings.Split(string(raw), "\n") {
+		if len(line) > 76 {
+			t.Errorf("encoded line longer than 76 characters: %q", line)
+		}
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(raw))))
+	if err != nil {
+		t.Fatalf("invalid quoted-printable body: %v", err)
+	}
+	if string(decoded) != body {
+		t.Errorf("body = %q, want %q", decoded, body)
+	}
+}
+
+func TestSendMailInvalidAddress(t *testing.T) {
+	cfg := SMTPConfig{Host: "127.0.0.1", Port: 1, Security: SecurityNone, From: "health@example.com"}
+	if err := sendMail(cfg, "not an address", "s", "b"); err == nil {
+		t.Error("sendMail with invalid recipient succeeded")
+	}
+	cfg.From = "invalid"
+	if err := sendMail(cfg, "alice@example.com", "s", "b"); err == nil {
+		t.Error("sendMail with invalid sender succeeded")
+	}
+}
+
+func TestSaveConfigPassword(t *testing.T) {
+	saved := SMTPConfig{Host: "smtp.example.com", Port: 587, Security: SecurityStartTLS, From: "health@example.com",
+		Username: "health", Password: "s3cret", Language: LanguageZh}
+
+	tests := []struct {
+		name   string
+		change func(c *SMTPConfig)
+		want   string
+	}{
+		{"未修改", func(c *SMTPConfig) {}, "s3cret"},
+		{"修改发件人", func(c *SMTPConfig) { c.From = "other@example.com" }, "s3cret"},
+		{"填写新密码", func(c *SMTPConfig) { c.Password = "new" }, "new"},
+		{"修改服务器", func(c *SMTPConfig) { c.Host = "smtp.attacker.example" }, ""},
+		{"修改端口", func(c *SMTPConfig) { c.Port = 465 }, ""},
+		{"修改用户名", func(c *SMTPConfig) { c.Username = "other" }, ""},
+		{"清空用户名", func(c *SMTPConfig) { c.Username = "" }, ""},
+	}
+	for _, tt := range tests {
+		m := NewMailer(newTestDB())
+		if err := m.SaveConfig(saved); err != nil {
+			t.Fatal(err)
+		}
+		cfg := saved
+		cfg.Password = ""
+		tt.change(&cfg)
+		if err := m.SaveConfig(cfg); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := m.LoadConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Password != tt.want {
+			t.Errorf("%s: password = %q, want %q", tt.name, got.Password, tt.want)
+		}
+		if value, _ := m.db.GetSetting(smtpSettingKey); tt.want != "" && strings.Contains(value, tt.want) {
+			t.Errorf("%s: %s contains the password: %s", tt.name, smtpSettingKey, value)
+		}
+	}
+}
+
+// userStore 在 settingsStore 的基础上提供用户查询
+type userStore struct {
+	*settingsStore
+	users map[int64]database.User
+}
+
+func (s *userStore) GetUserByID(id int64) (*database.User, error) {
+	u, ok := s.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &u, nil
+}
+
+func (s *userStore) GetUserSetting(userID int64, key string) (string, error) { return "", nil }
+
+func TestMailerSend(t *testing.T) {
+	store := &userStore{
+		settingsStore: &settingsStore{settings: map[string]string{}},
+		users: map[int64]database.User{
+			1: {ID: 1, Username: "alice", Email: "alice@example.com"},
+			2: {ID: 2, Username: "bob"},
+		},
+	}
+	m := NewMailer(database.NewManager(store, &config.DBConfig{Type: config.TypeBolt}))
+	port, received := fakeSMTP(t)
+	if err := m.SaveConfig(SMTPConfig{Enabled: true, Host: "127.0.0.1", Port: port, Security: SecurityNone,
+		From: "health@example.com", Language: LanguageZh}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有邮箱或不存在的用户不发送
+	for _, id := range []int64{2, 3} {
+		e := Event{Type: EventAlertTriggered, UserID: id, Time: time.Now(), Data: testEventData(EventAlertTriggered)}
+		if err := m.Send(e); err != nil {
+			t.Errorf("user %d: %v", id, err)
+		}
+	}
+
+	e := Event{Type: EventAlertTriggered, UserID: 1, Time: time.Now(), Data: testEventData(EventAlertTriggered)}
+	if err := m.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-received:
+		if msg.to != "RCPT TO:<alice@example.com>" {
+			t.Errorf("RCPT = %q", msg.to)
+		}
+		parsed, err := mail.ReadMessage(strings.NewReader(string(msg.data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+		if !strings.HasPrefix(string(body), "alice，您好") {
+			t.Errorf("body does not greet the user:\n%s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
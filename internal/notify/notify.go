@@ -0,0 +1,108 @@
+// Package notify 将系统事件分发到通知渠道，渠道之间互不影响
+package notify
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	EventRecordCreated  = "record.created"
+	EventRecordDeleted  = "record.deleted"
+	EventAlertTriggered = "alert.triggered"
+	EventUserCreated    = "user.created"
+	EventReminderDue    = "reminder.due" // 到了测量提醒时间仍未记录血压
+	EventWebhookTest    = "webhook.test" // 管理员测试 Webhook 时发送，不可订阅
+	EventEmailTest      = "email.test"   // 管理员测试邮件配置时发送，不可订阅
+)
+
+// Events 可订阅的事件类型
+var Events = []string{EventRecordCreated, EventRecordDeleted, EventAlertTriggered, EventUserCreated, EventReminderDue}
+
+// validEvent 判断是否为可订阅的事件类型
+func validEvent(eventType string) bool {
+	for _, e := range Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event 系统事件，Data 为事件相关的数据（如提醒事件），发送时序列化为JSON
+type Event struct {
+	Type   string      `json:"type"`
+	UserID int64       `json:"user_id"`
+	Time   time.Time   `json:"time"`
+	Data   interface{} `json:"data"`
+}
+
+// Channel 通知渠道。Send 可能涉及网络请求，由 Hub 在后台调用
+type Channel interface {
+	Name() string
+	Send(e Event) error
+}
+
+// Hub 将事件分发到已注册的全部通知渠道
+type Hub struct {
+	mu       sync.RWMutex
+	channels []Channel
+	wg       sync.WaitGroup
+}
+
+// NewHub 创建没有任何渠道的 Hub
+func NewHub() *Hub {
+	return &Hub{}
+}
+
+// Register 注册通知渠道
+func (h *Hub) Register(c Channel) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.channels = append(h.channels, c)
+}
+
+// Publish 在后台将事件发送到全部渠道，发送失败只记录日志，不影响调用方
+func (h *Hub) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	h.mu.RLock()
+	channels := append([]Channel(nil), h.channels...)
+	h.mu.RUnlock()
+
+	for _, c := range channels {
+		h.wg.Add(1)
+		go func(c Channel) {
+			defer h.wg.Done()
+			if err := c.Send(e); err != nil {
+				log.Printf("通知渠道 %s 发送 %s 事件失败: %v", c.Name(), e.Type, err)
+			}
+		}(c)
+	}
+}
+
+// Wait 等待已发出的通知全部完成，程序退出前调用
+func (h *Hub) Wait() {
+	h.wg.Wait()
+}
+
+// LogChannel 将事件写入日志，未配置其他渠道时也能在日志中看到提醒
+type LogChannel struct{}
+
+// Name 渠道名称
+func (LogChannel) Name() string { return "log" }
+
+// Send 将事件数据以JSON写入一行日志
+func (LogChannel) Send(e Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	log.Printf("[通知] %s 用户=%d %s", e.Type, e.UserID, data)
+	return nil
+}
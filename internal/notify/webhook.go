@@ -0,0 +1,337 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"health-manager/internal/database"
+)
+
+// webhookSettingKey Webhook 配置在全局设置中的键名
+const webhookSettingKey = "webhooks"
+
+// webhookDeliveriesKey 最近的投递记录在全局设置中的键名，重启后仍可查看
+const webhookDeliveriesKey = "webhook_deliveries"
+
+// webhookSecretsKey 各 Webhook 的签名密钥（按ID）单独保存在凭据设置中，不随逻辑备份导出
+const webhookSecretsKey = database.SecretSettingPrefix + "webhook_secrets"
+
+const (
+	webhookAttempts = 4   // 每次投递最多尝试次数（含首次）
+	webhookLogSize  = 100 // 投递日志保留的条数
+	webhookTimeout  = 10 * time.Second
+)
+
+// 签名与事件信息的请求头。签名为以密钥对请求体计算的 HMAC-SHA256，格式 "sha256=<hex>"
+const (
+	HeaderEvent     = "X-Health-Event"
+	HeaderDelivery  = "X-Health-Delivery"
+	HeaderSignature = "X-Health-Signature"
+)
+
+// Webhook 一个接收事件的地址。Events 为空表示接收全部事件
+type Webhook struct {
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret,omitempty"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+
+	// ClearSecret 保存时删除已保存的密钥，只在提交时使用，不会保存
+	ClearSecret bool `json:"clear_secret,omitempty"`
+}
+
+// Subscribed 判断是否接收该类型的事件
+func (w Webhook) Subscribed(eventType string) bool {
+	if len(w.Events) == 0 || eventType == EventWebhookTest {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWebhooks 校验地址和事件类型，并为新增的 Webhook 分配ID
+func validateWebhooks(hooks []Webhook) error {
+	next := 1
+	for _, w := range hooks {
+		if w.ID >= next {
+			next = w.ID + 1
+		}
+	}
+	seen := map[int]bool{}
+	for i := range hooks {
+		w := &hooks[i]
+		if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("Webhook 地址无效，应以 http:// 或 https:// 开头")
+		}
+		for _, e := range w.Events {
+			if !validEvent(e) {
+				return fmt.Errorf("不支持的事件类型: %s", e)
+			}
+		}
+		if w.ID <= 0 || seen[w.ID] {
+			w.ID = next
+			next++
+		}
+		seen[w.ID] = true
+	}
+	return nil
+}
+
+// Delivery 一次事件投递的结果
+type Delivery struct {
+	ID         string    `json:"id"`
+	WebhookID  int       `json:"webhook_id"`
+	Webhook    string    `json:"webhook"`
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Success    bool      `json:"success"`
+}
+
+// Webhooks 将事件以签名的JSON POST 到管理员配置的地址，失败时按指数退避重试
+type Webhooks struct {
+	db      *database.Manager
+	client  *http.Client
+	backoff time.Duration // 第一次重试前的等待时间，之后每次翻倍
+
+	mu sync.Mutex // 串行写入投递记录
+}
+
+// NewWebhooks 创建 Webhook 通知渠道
+func NewWebhooks(db *database.Manager) *Webhooks {
+	return &Webhooks{
+		db:      db,
+		client:  &http.Client{Timeout: webhookTimeout},
+		backoff: time.Second,
+	}
+}
+
+// LoadConfig 从全局设置读取 Webhook 列表
+func (w *Webhooks) LoadConfig() ([]Webhook, error) {
+	value, err := w.db.GetSetting(webhookSettingKey)
+	if err != nil || value == "" {
+		return []Webhook{}, err
+	}
+	var hooks []Webhook
+	if err := json.Unmarshal([]byte(value), &hooks); err != nil {
+		return []Webhook{}, err
+	}
+
+	secrets := map[int]string{}
+	if value, _ := w.db.GetSetting(webhookSecretsKey); value != "" {
+		json.Unmarshal([]byte(value), &secrets)
+	}
+	for i := range hooks {
+		hooks[i].Secret = secrets[hooks[i].ID]
+	}
+	return hooks, nil
+}
+
+// store 保存 Webhook 列表，密钥写入凭据设置，配置中不含密钥
+func (w *Webhooks) store(hooks []Webhook) error {
+	secrets := map[int]string{}
+	stripped := make([]Webhook, len(hooks))
+	for i, h := range hooks {
+		if h.Secret != "" {
+			secrets[h.ID] = h.Secret
+		}
+		h.Secret = ""
+		stripped[i] = h
+	}
+	data, _ := json.Marshal(secrets)
+	if err := w.db.SetSetting(webhookSecretsKey, string(data)); err != nil {
+		return err
+	}
+	data, _ = json.Marshal(stripped)
+	return w.db.SetSetting(webhookSettingKey, string(data))
+}
+
+// SaveConfig 校验并保存 Webhook 列表，未填写密钥时沿用同ID已保存的密钥，ClearSecret 为 true 时删除密钥
+func (w *Webhooks) SaveConfig(hooks []Webhook) error {
+	secrets := map[int]string{}
+	if old, err := w.LoadConfig(); err == nil {
+		for _, h := range old {
+			secrets[h.ID] = h.Secret
+		}
+	}
+	for i := range hooks {
+		if hooks[i].ClearSecret {
+			hooks[i].Secret = ""
+		} else if hooks[i].Secret == "" {
+			hooks[i].Secret = secrets[hooks[i].ID]
+		}
+		hooks[i].ClearSecret = false
+	}
+	if err := validateWebhooks(hooks); err != nil {
+		return err
+	}
+	return w.store(hooks)
+}
+
+// Deliveries 返回最近的投递记录，新的在前
+func (w *Webhooks) Deliveries() ([]Delivery, error) {
+	value, err := w.db.GetSetting(webhookDeliveriesKey)
+	if err != nil || value == "" {
+		return []Delivery{}, err
+	}
+	var list []Delivery
+	if err := json.Unmarshal([]byte(value), &list); err != nil {
+		return []Delivery{}, err
+	}
+	return list, nil
+}
+
+// record 将投递结果写入投递记录，只保留最近 webhookLogSize 条
+func (w *Webhooks) record(d Delivery) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	list, err := w.Deliveries()
+	if err != nil {
+		return err
+	}
+	list = append([]Delivery{d}, list...)
+	if len(list) > webhookLogSize {
+		list = list[:webhookLogSize]
+	}
+	data, _ := json.Marshal(list)
+	return w.db.SetSetting(webhookDeliveriesKey, string(data))
+}
+
+// Name 渠道名称
+func (w *Webhooks) Name() string { return "webhook" }
+
+// Send 将事件投递到所有订阅了该事件的已启用 Webhook，各地址并行投递
+func (w *Webhooks) Send(e Event) error {
+	hooks, err := w.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	for _, hook := range hooks {
+		if !hook.Enabled || !hook.Subscribed(e.Type) {
+			continue
+		}
+		wg.Add(1)
+		go func(hook Webhook) {
+			defer wg.Done()
+			if d := w.deliver(hook, e, webhookAttempts); !d.Success {
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}(hook)
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		return fmt.Errorf("%d 个 Webhook 投递失败", failed)
+	}
+	return nil
+}
+
+// Test 向指定 Webhook 发送一条测试事件（只尝试一次），返回投递结果
+func (w *Webhooks) Test(id int) (Delivery, error) {
+	hooks, err := w.LoadConfig()
+	if err != nil {
+		return Delivery{}, err
+	}
+	for _, hook := range hooks {
+		if hook.ID == id {
+			e := Event{Type: EventWebhookTest, Time: time.Now(), Data: map[string]string{"message": "测试消息"}}
+			return w.deliver(hook, e, 1), nil
+		}
+	}
+	return Delivery{}, fmt.Errorf("Webhook 不存在")
+}
+
+// deliver 投递事件，失败时等待 backoff、2*backoff…后重试，最多 attempts 次，结果写入投递日志
+func (w *Webhooks) deliver(hook Webhook, e Event, attempts int) Delivery {
+	d := Delivery{
+		ID:        fmt.Sprintf("%d-%d", time.Now().UnixNano(), hook.ID),
+		WebhookID: hook.ID,
+		Webhook:   hook.Name,
+		Event:     e.Type,
+		Time:      time.Now(),
+	}
+	body, _ := json.Marshal(struct {
+		ID string `json:"id"`
+		Event
+	}{d.ID, e})
+
+	wait := w.backoff
+	for d.Attempts < attempts {
+		if d.Attempts > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		d.Attempts++
+
+		retry := true
+		d.StatusCode, d.Error = 0, ""
+		resp, err := w.post(hook, e.Type, d.ID, body)
+		if err != nil {
+			d.Error = err.Error()
+		} else {
+			resp.Body.Close()
+			d.StatusCode = resp.StatusCode
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				d.Success = true
+				break
+			}
+			d.Error = resp.Status
+			// 4xx 表示请求本身有问题，重试也不会成功（超时与限流除外）
+			retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests ||
+				resp.StatusCode == http.StatusRequestTimeout
+		}
+		if !retry {
+			break
+		}
+	}
+
+	if err := w.record(d); err != nil {
+		log.Printf("保存 Webhook 投递记录失败: %v", err)
+	}
+	return d
+}
+
+// post 发送一次请求，设置了密钥时附带签名
+func (w *Webhooks) post(hook Webhook, eventType, deliveryID string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	if hook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+	}
+	return w.client.Do(req)
+}
+
+// Sign 计算请求体签名，接收方用相同密钥计算后与 X-Health-Signature 比较
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,232 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"health-manager/internal/config"
+	"health-manager/internal/database"
+)
+
+// settingsStore 只实现全局设置的内存 database.Store，其余方法由嵌入的 nil 接口提供，调用时会 panic
+type settingsStore struct {
+	database.Store
+	mu       sync.Mutex
+	settings map[string]string
+}
+
+func (s *settingsStore) GetSetting(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings[key], nil
+}
+
+func (s *settingsStore) SetSetting(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings[key] = value
+	return nil
+}
+
+// newTestDB 使用内存设置的数据库
+func newTestDB() *database.Manager {
+	return database.NewManager(&settingsStore{settings: map[string]string{}}, &config.DBConfig{Type: config.TypeBolt})
+}
+
+// newTestWebhooks 设置保存在内存中的 Webhook 渠道，重试间隔缩短为1毫秒
+func newTestWebhooks() *Webhooks {
+	return &Webhooks{db: newTestDB(), client: &http.Client{Timeout: time.Second}, backoff: time.Millisecond}
+}
+
+// statusServer 依次返回 statuses 中的状态码（用完后一直返回最后一个），并记录收到的请求
+type statusServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newStatusServer(t *testing.T, statuses ...int) *statusServer {
+	s := &statusServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		n := len(s.requests)
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+		s.mu.Unlock()
+		w.WriteHeader(s.statuses[min(n, len(s.statuses)-1)])
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSign(t *testing.T) {
+	got := Sign("key", []byte("The quick brown fox jumps over the lazy dog"))
+	want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestDeliverSignature(t *testing.T) {
+	srv := newStatusServer(t, http.StatusOK)
+	w := newTestWebhooks()
+	hook := Webhook{ID: 1, Name: "test", URL: srv.URL, Secret: "s3cret", Enabled: true}
+	e := Event{Type: EventRecordCreated, UserID: 7, Time: time.Now(), Data: map[string]int{"id": 42}}
+
+	d := w.deliver(hook, e, webhookAttempts)
+	if !d.Success || d.Attempts != 1 || d.StatusCode != http.StatusOK {
+		t.Fatalf("delivery = %+v, want success on first attempt", d)
+	}
+	if len(srv.requests) != 1 {
+		t.Fatalf("server got %d requests, want 1", len(srv.requests))
+	}
+
+	r, body := srv.requests[0], srv.bodies[0]
+	if got, want := r.Header.Get(HeaderSignature), Sign(hook.Secret, body); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	if got := r.Header.Get(HeaderEvent); got != EventRecordCreated {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, EventRecordCreated)
+	}
+	if got := r.Header.Get(HeaderDelivery); got != d.ID {
+		t.Errorf("%s = %q, want %q", HeaderDelivery, got, d.ID)
+	}
+
+	var payload struct {
+		ID     string `json:"id"`
+		Type   string `json:"type"`
+		UserID int64  `json:"user_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("invalid body %s: %v", body, err)
+	}
+	if payload.ID != d.ID || payload.Type != EventRecordCreated || payload.UserID != 7 {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestDeliverWithoutSecret(t *testing.T) {
+	srv := newStatusServer(t, http.StatusNoContent)
+	d := newTestWebhooks().deliver(Webhook{ID: 1, URL: srv.URL}, Event{Type: EventUserCreated}, webhookAttempts)
+	if !d.Success {
+		t.Fatalf("delivery = %+v, want success", d)
+	}
+	if got := srv.requests[0].Header.Get(HeaderSignature); got != "" {
+		t.Errorf("%s = %q, want empty without secret", HeaderSignature, got)
+	}
+}
+
+func TestDeliverRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+		status   int
+		success  bool
+	}{
+		{"5xx 后成功", []int{500, 502, 200}, 3, 200, true},
+		{"429 后成功", []int{429, 200}, 2, 200, true},
+		{"408 后成功", []int{408, 200}, 2, 200, true},
+		{"5xx 用完重试次数", []int{503}, webhookAttempts, 503, false},
+		{"429 用完重试次数", []int{429}, webhookAttempts, 429, false},
+		{"400 不重试", []int{400, 200}, 1, 400, false},
+		{"404 不重试", []int{404, 200}, 1, 404, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStatusServer(t, tt.statuses...)
+			d := newTestWebhooks().deliver(Webhook{ID: 1, URL: srv.URL}, Event{Type: EventAlertTriggered}, webhookAttempts)
+			if d.Attempts != tt.attempts || len(srv.requests) != tt.attempts {
+				t.Errorf("attempts = %d, requests = %d, want %d", d.Attempts, len(srv.requests), tt.attempts)
+			}
+			if d.StatusCode != tt.status || d.Success != tt.success {
+				t.Errorf("status = %d success = %v, want %d %v", d.StatusCode, d.Success, tt.status, tt.success)
+			}
+			if !tt.success && d.Error == "" {
+				t.Error("failed delivery has no error")
+			}
+		})
+	}
+}
+
+func TestDeliverConnectionError(t *testing.T) {
+	srv := newStatusServer(t, http.StatusOK)
+	url := srv.URL
+	srv.Close()
+
+	d := newTestWebhooks().deliver(Webhook{ID: 1, URL: url}, Event{Type: EventAlertTriggered}, 2)
+	if d.Success || d.Attempts != 2 || d.Error == "" || d.StatusCode != 0 {
+		t.Fatalf("delivery = %+v, want 2 failed attempts with error", d)
+	}
+}
+
+func TestDeliveryLogSize(t *testing.T) {
+	srv := newStatusServer(t, http.StatusOK)
+	w := newTestWebhooks()
+	for i := 0; i < webhookLogSize+5; i++ {
+		w.deliver(Webhook{ID: i, URL: srv.URL}, Event{Type: EventRecordCreated}, 1)
+	}
+
+	// 投递记录保存在数据库中，重新创建渠道（相当于重启）后仍在
+	log, err := NewWebhooks(w.db).Deliveries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != webhookLogSize {
+		t.Fatalf("log size = %d, want %d", len(log), webhookLogSize)
+	}
+	// 新的在前，最早的5条被丢弃
+	if log[0].WebhookID != webhookLogSize+4 || log[len(log)-1].WebhookID != 5 {
+		t.Errorf("log range = %d..%d, want %d..5", log[0].WebhookID, log[len(log)-1].WebhookID, webhookLogSize+4)
+	}
+}
+
+func TestSaveConfigSecret(t *testing.T) {
+	w := newTestWebhooks()
+	save := func(hooks ...Webhook) map[int]string {
+		t.Helper()
+		if err := w.SaveConfig(hooks); err != nil {
+			t.Fatal(err)
+		}
+		saved, err := w.LoadConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		secrets := map[int]string{}
+		for _, h := range saved {
+			if h.ClearSecret {
+				t.Errorf("webhook %d saved with clear_secret", h.ID)
+			}
+			secrets[h.ID] = h.Secret
+		}
+		return secrets
+	}
+	hook := func(id int, secret string, clear bool) Webhook {
+		return Webhook{ID: id, URL: "https://example.com/hook", Secret: secret, ClearSecret: clear}
+	}
+
+	if got := save(hook(1, "one", false), hook(2, "two", false)); got[1] != "one" || got[2] != "two" {
+		t.Fatalf("secrets = %v", got)
+	}
+	// 留空沿用原密钥，填写则替换，ClearSecret 删除
+	if got := save(hook(1, "", false), hook(2, "", true)); got[1] != "one" || got[2] != "" {
+		t.Errorf("keep/clear: secrets = %v", got)
+	}
+	if got := save(hook(1, "new", false), hook(2, "", false)); got[1] != "new" || got[2] != "" {
+		t.Errorf("replace: secrets = %v", got)
+	}
+
+	// 配置中不含密钥
+	if value, _ := w.db.GetSetting(webhookSettingKey); strings.Contains(value, "new") {
+		t.Errorf("%s contains a secret: %s", webhookSettingKey, value)
+	}
+}
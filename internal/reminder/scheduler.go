@@ -0,0 +1,137 @@
+// Package reminder 按用户设置的时间检查是否已测量血压，未测量时通过通知渠道发送提醒
+package reminder
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"health-manager/internal/database"
+	"health-manager/internal/health"
+	"health-manager/internal/notify"
+)
+
+// SettingKey 测量提醒在用户设置中的键名
+const SettingKey = "reminders"
+
+// checkInterval 检查间隔，提醒最多延迟这么久发出
+const checkInterval = time.Minute
+
+// Load 读取用户的测量提醒，未设置或损坏时返回 nil
+func Load(db *database.Manager, userID int64) []health.Reminder {
+	value, _ := db.GetUserSetting(userID, SettingKey)
+	if value == "" {
+		return nil
+	}
+	var reminders []health.Reminder
+	if err := json.Unmarshal([]byte(value), &reminders); err != nil || health.ValidateReminders(reminders) != nil {
+		return nil
+	}
+	return reminders
+}
+
+// Due 发送的提醒内容，时间均为用户时区
+type Due struct {
+	Time  string    `json:"time"` // 设置的提醒时间 HH:MM
+	Start time.Time `json:"window_start"`
+	End   time.Time `json:"window_end"`
+}
+
+// Scheduler 每分钟检查一次各用户到点的测量提醒，检查时段内没有血压记录时发布 reminder.due 事件
+type Scheduler struct {
+	db       *database.Manager
+	notifier *notify.Hub
+	location func(userID int64) *time.Location
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewScheduler 创建测量提醒调度器，location 返回用户所在时区
+func NewScheduler(db *database.Manager, notifier *notify.Hub, location func(userID int64) *time.Location) *Scheduler {
+	return &Scheduler{
+		db:       db,
+		notifier: notifier,
+		location: location,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start 启动后台检查
+func (s *Scheduler) Start() {
+	go s.loop()
+}
+
+// Stop 停止后台检查并等待退出
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.check(last, now)
+			last = now
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// check 处理提醒时间落在 (from, to] 内的提醒。只检查最近一次提醒时间，程序暂停期间错过的不补发
+func (s *Scheduler) check(from, to time.Time) {
+	users, err := s.db.GetAllUsers()
+	if err != nil {
+		log.Printf("测量提醒读取用户失败: %v", err)
+		return
+	}
+	for _, u := range users {
+		reminders := Load(s.db, u.ID)
+		if len(reminders) == 0 {
+			continue
+		}
+		now := to.In(s.location(u.ID))
+		for _, r := range reminders {
+			due := r.Due(now)
+			if !due.After(from) {
+				continue
+			}
+			start := due.Add(-r.WindowDuration())
+			measured, err := s.measured(u.ID, start, now)
+			if err != nil {
+				log.Printf("测量提醒查询记录失败: %v", err)
+				continue
+			}
+			if !measured {
+				s.notifier.Publish(notify.Event{
+					Type:   notify.EventReminderDue,
+					UserID: u.ID,
+					Time:   due,
+					Data:   Due{Time: r.Time, Start: start, End: due},
+				})
+			}
+		}
+	}
+}
+
+// measured 判断 [start, end) 内是否有血压记录，只记录了体重等数据的不算
+func (s *Scheduler) measured(userID int64, start, end time.Time) (bool, error) {
+	records, err := s.db.GetBPRecords(userID, start, end)
+	if err != nil {
+		return false, err
+	}
+	for _, bp := range records {
+		if bp.Systolic > 0 || bp.Diastolic > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}